@@ -1,12 +1,22 @@
 package main
 
 import (
+	"archive/zip"
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/aditya01933/paramguard/scanner"
+	"gopkg.in/yaml.v3"
 )
 
 // TestE2E_VulnerableConfig tests scanning a config with multiple issues
@@ -338,3 +348,1971 @@ func TestE2E_InvalidConfigFile(t *testing.T) {
 		})
 	}
 }
+
+// buildTestBinary builds the paramguard binary used by the e2e tests and
+// returns its path
+func buildTestBinary(t *testing.T) string {
+	t.Helper()
+
+	buildCmd := exec.Command("go", "build", "-o", "paramguard-test")
+	if output, err := buildCmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build: %v\n%s", err, output)
+	}
+	t.Cleanup(func() { os.Remove("paramguard-test") })
+
+	return "./paramguard-test"
+}
+
+// TestE2E_CSVOutput tests CSV output format
+func TestE2E_CSVOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: CSV_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    description: 'Temperature is high, which "raises" risk'
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+    recommendation: "Lower it, please"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	badConfig := filepath.Join(tmpDir, "bad.json")
+	goodConfig := filepath.Join(tmpDir, "good.json")
+	if err := os.WriteFile(badConfig, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(goodConfig, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	header := []string{"file", "rule_id", "name", "severity", "category", "location", "description", "recommendation"}
+
+	// Findings produce one row each
+	output, _ := exec.Command(binary, "scan", "--rules", rulesFile, "--format", "csv", badConfig).Output()
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV output: %v\nOutput: %s", err, output)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected header + 1 row, got %d records", len(records))
+	}
+	for _, record := range records {
+		if len(record) != len(header) {
+			t.Errorf("expected %d columns, got %d: %v", len(header), len(record), record)
+		}
+	}
+	want := []string{badConfig, "CSV_001", "High Temperature", "HIGH", "parameters", "temperature", `Temperature is high, which "raises" risk`, "Lower it, please"}
+	for i := range want {
+		if records[1][i] != want[i] {
+			t.Errorf("column %s = %q, want %q", header[i], records[1][i], want[i])
+		}
+	}
+
+	// Header is emitted even with zero findings
+	output, err = exec.Command(binary, "scan", "--rules", rulesFile, "--format", "csv", goodConfig).Output()
+	if err != nil {
+		t.Fatalf("expected zero exit code, got %v", err)
+	}
+	records, err = csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to parse CSV output: %v", err)
+	}
+	if len(records) != 1 || strings.Join(records[0], ",") != strings.Join(header, ",") {
+		t.Errorf("expected only the header row, got %v", records)
+	}
+}
+
+// TestE2E_ContinueOnError tests that parse failures are reported without
+// losing results for the other files
+func TestE2E_ContinueOnError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	validConfig := filepath.Join(tmpDir, "valid.json")
+	invalidConfig := filepath.Join(tmpDir, "invalid.json")
+	if err := os.WriteFile(validConfig, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(invalidConfig, []byte(`{"temperature": `), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	// Default policy aborts on the first parse failure
+	output, err := exec.Command(binary, "scan", "--format", "json", invalidConfig, validConfig).Output()
+	if err == nil {
+		t.Error("expected non-zero exit code with --fail-on-error default")
+	}
+	if len(output) != 0 {
+		t.Errorf("expected no report on stdout when aborting, got: %s", output)
+	}
+
+	output, err = exec.Command(binary, "scan", "--continue-on-error", "--format", "json", invalidConfig, validConfig).Output()
+	if err == nil {
+		t.Error("expected non-zero exit code when a file fails to parse")
+	}
+
+	var result struct {
+		Results []struct {
+			File     string `json:"file"`
+			Error    string `json:"error"`
+			Findings []struct {
+				RuleID string `json:"rule_id"`
+			} `json:"findings"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+
+	if len(result.Results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(result.Results))
+	}
+	if result.Results[0].File != invalidConfig || result.Results[0].Error == "" {
+		t.Errorf("expected an error for %s, got %+v", invalidConfig, result.Results[0])
+	}
+	if result.Results[1].Error != "" || len(result.Results[1].Findings) == 0 {
+		t.Errorf("expected findings for %s, got %+v", validConfig, result.Results[1])
+	}
+}
+
+// TestE2E_MinDisplaySeverity tests that filtered findings are still counted
+func TestE2E_MinDisplaySeverity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: LOW_001
+    name: "Seed Present"
+    severity: LOW
+    category: parameters
+    description: "Seed is set"
+    check:
+      type: field_exists
+      field: seed
+    recommendation: "Remove seed"
+  - id: HIGH_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    description: "Temperature too high"
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+    recommendation: "Lower temperature"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"seed": 42, "temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--rules", rulesFile, "--min-display-severity", "MEDIUM", configFile).Output()
+	outputStr := string(output)
+
+	if strings.Contains(outputStr, "LOW_001") {
+		t.Errorf("LOW finding should not be listed, got: %s", outputStr)
+	}
+	if !strings.Contains(outputStr, "HIGH_001") {
+		t.Errorf("HIGH finding should be listed, got: %s", outputStr)
+	}
+	if !strings.Contains(outputStr, "Total findings: 2") || !strings.Contains(outputStr, "Low: 1") {
+		t.Errorf("summary should count the hidden LOW finding, got: %s", outputStr)
+	}
+
+	output, _ = exec.Command(binary, "scan", "--rules", rulesFile, "--min-display-severity", "MEDIUM", "--format", "json", configFile).Output()
+	var result struct {
+		Summary struct {
+			TotalFindings  int            `json:"total_findings"`
+			BySeverity     map[string]int `json:"by_severity"`
+			HiddenFindings int            `json:"hidden_findings"`
+		} `json:"summary"`
+		Results []struct {
+			Findings []struct {
+				RuleID string `json:"rule_id"`
+			} `json:"findings"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if result.Summary.TotalFindings != 2 || result.Summary.BySeverity["LOW"] != 1 || result.Summary.HiddenFindings != 1 {
+		t.Errorf("unexpected summary: %+v", result.Summary)
+	}
+	if len(result.Results[0].Findings) != 1 || result.Results[0].Findings[0].RuleID != "HIGH_001" {
+		t.Errorf("expected only HIGH_001 listed, got %+v", result.Results[0].Findings)
+	}
+}
+
+// TestE2E_Fix tests that --fix writes a corrected copy next to the config
+func TestE2E_Fix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	configFile := filepath.Join(tmpDir, "config.json")
+	original := `{"temperature": 1.5}`
+	if err := os.WriteFile(configFile, []byte(original), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	if err := exec.Command(binary, "scan", "--fix", configFile).Run(); err == nil {
+		t.Error("expected non-zero exit code for the original findings")
+	}
+
+	data, err := os.ReadFile(configFile + ".fixed")
+	if err != nil {
+		t.Fatalf("expected fixed file: %v", err)
+	}
+	var fixed map[string]interface{}
+	if err := json.Unmarshal(data, &fixed); err != nil {
+		t.Fatalf("fixed file is not valid JSON: %v\n%s", err, data)
+	}
+	// Several default rules bound temperature; the strictest max wins
+	if temp, ok := fixed["temperature"].(float64); !ok || temp > 1.0 {
+		t.Errorf("temperature = %v, want clamped to at most 1.0", fixed["temperature"])
+	}
+
+	if data, _ := os.ReadFile(configFile); string(data) != original {
+		t.Errorf("original file should be untouched without --in-place, got %s", data)
+	}
+
+	// A format that can't be written back is skipped, not fatal
+	jsoncFile := filepath.Join(tmpDir, "a.jsonc")
+	if err := os.WriteFile(jsoncFile, []byte("// comments can't be written back\n{\"temperature\": 1.5}\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	os.Remove(configFile + ".fixed")
+	output, err := exec.Command(binary, "scan", "--fix", jsoncFile, configFile).CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Errorf("expected exit code 1 for the findings, got %v: %s", err, output)
+	}
+	if !strings.Contains(string(output), "Warning: skipping fix for "+jsoncFile) {
+		t.Errorf("expected a warning for the .jsonc file, got: %s", output)
+	}
+	if _, err := os.Stat(configFile + ".fixed"); err != nil {
+		t.Errorf("expected the JSON file after it to still be fixed: %v", err)
+	}
+}
+
+// TestE2E_RulesList tests that every loaded rule is listed
+func TestE2E_RulesList(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	data, err := os.ReadFile(filepath.Join("scanner", "rules.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read scanner/rules.yaml: %v", err)
+	}
+	var rulesFile struct {
+		Rules []struct {
+			ID       string `yaml:"id"`
+			Severity string `yaml:"severity"`
+		} `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &rulesFile); err != nil {
+		t.Fatalf("failed to parse rules.yaml: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "rules", "list").Output()
+	if err != nil {
+		t.Fatalf("rules list failed: %v", err)
+	}
+	for _, rule := range rulesFile.Rules {
+		if !strings.Contains(string(output), rule.ID) {
+			t.Errorf("rules list output missing %s", rule.ID)
+		}
+	}
+
+	output, err = exec.Command(binary, "rules", "list", "--format", "json", "--severity", "critical").Output()
+	if err != nil {
+		t.Fatalf("rules list --format json failed: %v", err)
+	}
+	var listed []struct {
+		ID       string `json:"id"`
+		Severity string `json:"severity"`
+	}
+	if err := json.Unmarshal(output, &listed); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	wantCritical := 0
+	for _, rule := range rulesFile.Rules {
+		if rule.Severity == "CRITICAL" {
+			wantCritical++
+		}
+	}
+	if len(listed) != wantCritical {
+		t.Errorf("expected %d CRITICAL rules, got %d", wantCritical, len(listed))
+	}
+	for _, rule := range listed {
+		if rule.Severity != "CRITICAL" {
+			t.Errorf("--severity filter leaked %s (%s)", rule.ID, rule.Severity)
+		}
+	}
+}
+
+// TestE2E_OutputFile tests writing the report to a file
+func TestE2E_OutputFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	reportFile := filepath.Join(tmpDir, "report.json")
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "scan", "--format", "json", "--output", reportFile, configFile).Output()
+	if err == nil {
+		t.Error("expected non-zero exit code")
+	}
+	if len(output) != 0 {
+		t.Errorf("expected nothing on stdout, got: %s", output)
+	}
+
+	data, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("expected report file: %v", err)
+	}
+	var result struct {
+		Version string `json:"version"`
+		Results []struct {
+			File string `json:"file"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("report is not valid JSON: %v\n%s", err, data)
+	}
+	if len(result.Results) != 1 || result.Results[0].File != configFile {
+		t.Errorf("unexpected results in report: %+v", result.Results)
+	}
+
+	// "-" writes to stdout
+	output, _ = exec.Command(binary, "scan", "--output", "-", configFile).Output()
+	if !strings.Contains(string(output), "SUMMARY") {
+		t.Errorf("expected text report on stdout, got: %s", output)
+	}
+}
+
+// TestE2E_ShowPassed tests that passed rules are reported on request
+func TestE2E_ShowPassed(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--show-passed", "--format", "json", configFile).Output()
+	var result struct {
+		Results []struct {
+			Passed []string `json:"passed"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	passed := strings.Join(result.Results[0].Passed, ",")
+	if !strings.Contains(passed, "TEMP_001") || !strings.Contains(passed, "SECRETS_001") {
+		t.Errorf("expected TEMP_001 and SECRETS_001 to pass, got %v", passed)
+	}
+
+	output, _ = exec.Command(binary, "scan", "--show-passed", configFile).Output()
+	if !strings.Contains(string(output), "Passed (") {
+		t.Errorf("expected passed rules in text output, got: %s", output)
+	}
+}
+
+// TestE2E_Diff tests comparing two saved JSON reports
+func TestE2E_Diff(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	baseReport := filepath.Join(tmpDir, "base.json")
+	headReport := filepath.Join(tmpDir, "head.json")
+
+	binary := buildTestBinary(t)
+
+	scan := func(content, report string) {
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		exec.Command(binary, "scan", "--format", "json", "--output", report, configFile).Run()
+	}
+	scan(`{"temperature": 1.5, "seed": 1}`, baseReport)
+	scan(`{"temperature": 1.5, "api_key": "sk-test1234567890abcdefghijklmnopqr"}`, headReport)
+
+	output, err := exec.Command(binary, "diff", "--show-resolved", baseReport, headReport).Output()
+	if err == nil {
+		t.Error("expected non-zero exit code when new findings exist")
+	}
+	outputStr := string(output)
+	if !strings.Contains(outputStr, "+ "+configFile+": SECRETS_001") {
+		t.Errorf("expected SECRETS_001 as a new finding, got: %s", outputStr)
+	}
+	if !strings.Contains(outputStr, "- "+configFile+": SEED_001") {
+		t.Errorf("expected SEED_001 as a resolved finding, got: %s", outputStr)
+	}
+	if strings.Contains(outputStr, "+ "+configFile+": TEMP_001") {
+		t.Errorf("unchanged TEMP_001 should not be reported as new, got: %s", outputStr)
+	}
+
+	// Diffing a report against itself finds nothing new
+	if output, err := exec.Command(binary, "diff", baseReport, baseReport).Output(); err != nil {
+		t.Errorf("expected zero exit code, got %v: %s", err, output)
+	}
+
+	// Findings carry a fingerprint that redaction doesn't change
+	report, err := os.ReadFile(headReport)
+	if err != nil {
+		t.Fatalf("failed to read report: %v", err)
+	}
+	if !strings.Contains(string(report), `"fingerprint": "`) {
+		t.Errorf("expected findings to carry a fingerprint, got: %s", report)
+	}
+	redactedReport := filepath.Join(tmpDir, "redacted.json")
+	exec.Command(binary, "scan", "--format", "json", "--redact-output", "--output", redactedReport, configFile).Run()
+	if output, err := exec.Command(binary, "diff", headReport, redactedReport).Output(); err != nil {
+		t.Errorf("expected a redacted report to match the unredacted one, got %v: %s", err, output)
+	}
+}
+
+func TestE2E_RelativeTo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "configs", "prod", "config.json")
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		t.Fatalf("failed to create config dir: %v", err)
+	}
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--format", "json", "--relative-to", tmpDir, configFile).Output()
+
+	var result struct {
+		Results []struct {
+			File         string `json:"file"`
+			AbsolutePath string `json:"absolute_path"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if len(result.Results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(result.Results))
+	}
+	if got := result.Results[0].File; got != "configs/prod/config.json" {
+		t.Errorf("file = %q, want configs/prod/config.json", got)
+	}
+	if got := result.Results[0].AbsolutePath; got != configFile {
+		t.Errorf("absolute_path = %q, want %q", got, configFile)
+	}
+}
+
+func TestE2E_NoFail(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "vulnerable.json")
+	configContent := `{"temperature": 1.5, "api_key": "sk-test1234567890abcdefghijklmnopqr"}`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "scan", "--no-fail", configFile).CombinedOutput()
+	if err != nil {
+		t.Errorf("expected exit code 0 with --no-fail, got %v", err)
+	}
+	if !strings.Contains(string(output), "SECRETS_001") {
+		t.Errorf("findings should still be reported, got: %s", output)
+	}
+
+	// Parse errors still fail the run
+	badFile := filepath.Join(tmpDir, "broken.json")
+	if err := os.WriteFile(badFile, []byte(`{"temperature": `), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := exec.Command(binary, "scan", "--no-fail", "--continue-on-error", badFile).Run(); err == nil {
+		t.Error("expected non-zero exit code for an unparseable file")
+	}
+}
+
+// TestE2E_Presets tests the built-in --preset presets
+func TestE2E_Presets(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "vulnerable.json")
+	configContent := `{"temperature": 1.5, "api_key": "sk-test1234567890abcdefghijklmnopqr"}`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	// test reports findings but never fails
+	output, err := exec.Command(binary, "scan", "--preset", "test", configFile).CombinedOutput()
+	if err != nil {
+		t.Errorf("expected exit code 0 with --preset test, got %v: %s", err, output)
+	}
+	if !strings.Contains(string(output), "SECRETS_001") {
+		t.Errorf("findings should still be reported, got: %s", output)
+	}
+
+	if err := exec.Command(binary, "scan", "--preset", "prod", configFile).Run(); err == nil {
+		t.Error("expected non-zero exit code with --preset prod")
+	}
+
+	// dev reports secrets at MEDIUM unless --set-severity names the rule
+	severityOf := func(args ...string) string {
+		output, _ := exec.Command(binary, append([]string{"scan", "--format", "json"}, args...)...).Output()
+		var report struct {
+			Results []struct {
+				Findings []struct {
+					RuleID   string `json:"rule_id"`
+					Severity string `json:"severity"`
+				} `json:"findings"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(output, &report); err != nil || len(report.Results) != 1 {
+			t.Fatalf("failed to parse JSON output: %v\n%s", err, output)
+		}
+		for _, finding := range report.Results[0].Findings {
+			if finding.RuleID == "SECRETS_001" {
+				return finding.Severity
+			}
+		}
+		return ""
+	}
+	if got := severityOf("--preset", "dev", configFile); got != "MEDIUM" {
+		t.Errorf("expected SECRETS_001 at MEDIUM with --preset dev, got %q", got)
+	}
+	if got := severityOf("--preset", "dev", "--set-severity", "SECRETS_001=HIGH", configFile); got != "HIGH" {
+		t.Errorf("expected --set-severity to win over --preset dev, got %q", got)
+	}
+
+	if err := exec.Command(binary, "scan", "--preset", "staging", configFile).Run(); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+}
+
+func TestE2E_JSONSummaryWhenClean(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "scan", "--rules", rulesFile, "--format", "json", configFile).Output()
+	if err != nil {
+		t.Fatalf("expected a clean scan, got %v\n%s", err, output)
+	}
+
+	var result struct {
+		ScannedAt string `json:"scanned_at"`
+		Summary   *struct {
+			TotalFiles    int            `json:"total_files"`
+			TotalFindings int            `json:"total_findings"`
+			BySeverity    map[string]int `json:"by_severity"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+
+	if result.Summary == nil {
+		t.Fatalf("expected a summary object, got: %s", output)
+	}
+	if result.Summary.TotalFiles != 1 || result.Summary.TotalFindings != 0 {
+		t.Errorf("unexpected summary counts: %+v", result.Summary)
+	}
+	for _, severity := range []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"} {
+		count, ok := result.Summary.BySeverity[severity]
+		if !ok || count != 0 {
+			t.Errorf("by_severity[%s] = %d (present: %v), want explicit 0", severity, count, ok)
+		}
+	}
+	if _, err := time.Parse(time.RFC3339, result.ScannedAt); err != nil {
+		t.Errorf("scanned_at %q is not RFC3339: %v", result.ScannedAt, err)
+	}
+}
+
+func TestE2E_Explain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    description: "Temperature too high"
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.1
+      max: 1.2
+    recommendation: "Lower temperature"
+    references:
+      - "Test reference"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "explain", "--rules", rulesFile, "TEMP_001").CombinedOutput()
+	if err != nil {
+		t.Fatalf("explain failed: %v\n%s", err, output)
+	}
+	for _, want := range []string{"TEMP_001", "numeric_range", "temperature", "Min:", "0.1", "Max:", "1.2", "HIGH", "Test reference"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("explanation missing %q:\n%s", want, output)
+		}
+	}
+
+	if err := exec.Command(binary, "explain", "--rules", rulesFile, "NOPE_001").Run(); err == nil {
+		t.Error("expected an error for an unknown rule ID")
+	}
+}
+
+func TestE2E_ColorNever(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: CRITICAL
+    category: parameters
+    description: "Temperature too high"
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+    recommendation: "Lower temperature"
+    references:
+      - "Test reference"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	badFile := filepath.Join(tmpDir, "config.json")
+	okFile := filepath.Join(tmpDir, "ok.json")
+	if err := os.WriteFile(badFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(okFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--rules", rulesFile, "--color", "never", badFile, okFile).Output()
+	for i, b := range output {
+		if b >= 0x80 {
+			t.Fatalf("unexpected non-ASCII byte at offset %d:\n%s", i, output)
+		}
+	}
+	for _, want := range []string{"[CRITICAL] High Temperature", "[OK]", "SUMMARY"} {
+		if !strings.Contains(string(output), want) {
+			t.Errorf("plain output missing %q:\n%s", want, output)
+		}
+	}
+
+	// NO_COLOR is overridden by an explicit --color always
+	cmd := exec.Command(binary, "scan", "--rules", rulesFile, "--color", "always", badFile)
+	cmd.Env = append(os.Environ(), "NO_COLOR=1")
+	output, _ = cmd.Output()
+	if !strings.Contains(string(output), "🔴") {
+		t.Errorf("expected emoji with --color always:\n%s", output)
+	}
+}
+
+func TestE2E_TextOutputCharacters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	badFile := filepath.Join(tmpDir, "config.json")
+	okFile := filepath.Join(tmpDir, "ok.yaml")
+	if err := os.WriteFile(badFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(okFile, []byte("name: demo\n"), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--color", "always", badFile).Output()
+	outputStr := string(output)
+	for _, want := range []string{"━━━━", "📄 ", "📊 SUMMARY", "💡 "} {
+		if !strings.Contains(outputStr, want) {
+			t.Errorf("output missing %q:\n%s", want, outputStr)
+		}
+	}
+
+	// UTF-8 decoded as Latin-1 shows up as these sequences
+	for _, garbled := range []string{"â”", "ðŸ", "âœ"} {
+		if strings.Contains(outputStr, garbled) {
+			t.Errorf("output contains mojibake %q:\n%s", garbled, outputStr)
+		}
+	}
+
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	output, _ = exec.Command(binary, "scan", "--rules", rulesFile, "--color", "always", okFile).Output()
+	if !strings.Contains(string(output), "✓ ") {
+		t.Errorf("expected a check mark for a clean file:\n%s", output)
+	}
+}
+
+func TestE2E_GitHubFormat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	configContent := `{"temperature": 1.5, "api_key": "sk-test1234567890abcdefghijklmnopqr"}`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	summaryFile := filepath.Join(tmpDir, "summary.md")
+
+	binary := buildTestBinary(t)
+
+	cmd := exec.Command(binary, "scan", "--format", "github", configFile)
+	cmd.Env = append(os.Environ(), "GITHUB_STEP_SUMMARY="+summaryFile)
+	output, err := cmd.Output()
+	if err == nil {
+		t.Error("expected non-zero exit code")
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) == 0 || lines[0] == "" {
+		t.Fatal("expected annotation lines")
+	}
+	foundSecret := false
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "::error file=") && !strings.HasPrefix(line, "::warning file=") && !strings.HasPrefix(line, "::notice file=") {
+			t.Errorf("malformed annotation: %q", line)
+			continue
+		}
+		props, message, ok := strings.Cut(strings.TrimPrefix(line, "::"), "::")
+		if !ok || message == "" {
+			t.Errorf("annotation has no message: %q", line)
+		}
+		if !strings.Contains(props, ",title=") {
+			t.Errorf("annotation has no title: %q", line)
+		}
+		if strings.Contains(line, "SECRETS_001") {
+			foundSecret = true
+			if !strings.HasPrefix(line, "::error ") {
+				t.Errorf("CRITICAL finding should be an error annotation: %q", line)
+			}
+			if !strings.Contains(props, ",line=1,") {
+				t.Errorf("annotation should point at line 1: %q", line)
+			}
+		}
+	}
+	if !foundSecret {
+		t.Errorf("expected an annotation for SECRETS_001:\n%s", output)
+	}
+
+	summary, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatalf("expected job summary file: %v", err)
+	}
+	if !strings.Contains(string(summary), "## ParamGuard scan results") || !strings.Contains(string(summary), "SECRETS_001") {
+		t.Errorf("unexpected job summary:\n%s", summary)
+	}
+}
+
+func TestE2E_Quiet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	cleanFile := filepath.Join(tmpDir, "clean.json")
+	badFile := filepath.Join(tmpDir, "bad.json")
+	if err := os.WriteFile(cleanFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(badFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "scan", "--rules", rulesFile, "--quiet", cleanFile).Output()
+	if err != nil {
+		t.Errorf("expected exit code 0 for a clean file, got %v", err)
+	}
+	if len(output) != 0 {
+		t.Errorf("expected no output for a clean file under --quiet, got:\n%s", output)
+	}
+
+	output, _ = exec.Command(binary, "scan", "--rules", rulesFile, "--quiet", cleanFile, badFile).Output()
+	outputStr := string(output)
+	if !strings.Contains(outputStr, "TEMP_001") || !strings.Contains(outputStr, "bad.json") {
+		t.Errorf("expected the vulnerable file's findings, got:\n%s", outputStr)
+	}
+	if strings.Contains(outputStr, "clean.json") || strings.Contains(outputStr, "SUMMARY") {
+		t.Errorf("clean files and the summary should be omitted, got:\n%s", outputStr)
+	}
+}
+
+// TestE2E_RulesSchema tests that the rules schema is valid JSON and
+// enumerates every supported check type
+func TestE2E_RulesSchema(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	binary := buildTestBinary(t)
+
+	output, err := exec.Command(binary, "rules", "schema").Output()
+	if err != nil {
+		t.Fatalf("rules schema failed: %v\n%s", err, output)
+	}
+
+	var schema struct {
+		Defs struct {
+			Check struct {
+				Properties map[string]struct {
+					Enum []string `json:"enum"`
+				} `json:"properties"`
+			} `json:"Check"`
+		} `json:"$defs"`
+		Properties map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(output, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v\n%s", err, output)
+	}
+
+	for _, key := range []string{"version", "rules", "categories", "profiles", "templates"} {
+		if _, ok := schema.Properties[key]; !ok {
+			t.Errorf("schema is missing top-level property %q", key)
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, checkType := range schema.Defs.Check.Properties["type"].Enum {
+		listed[checkType] = true
+	}
+	checkTypes := scanner.CheckTypes()
+	if len(checkTypes) == 0 {
+		t.Fatal("no check types registered")
+	}
+	for _, checkType := range checkTypes {
+		if !listed[checkType] {
+			t.Errorf("schema does not list check type %q", checkType)
+		}
+	}
+}
+
+// TestE2E_DefaultsFile tests that .paramguard.yaml settings apply when no
+// flag is given and that flags override them
+func TestE2E_DefaultsFile(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.9}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, ".paramguard.yaml"), []byte("format: json\nno_fail: true\n"), 0644); err != nil {
+		t.Fatalf("failed to write defaults file: %v", err)
+	}
+
+	binary, err := filepath.Abs(buildTestBinary(t))
+	if err != nil {
+		t.Fatalf("failed to resolve binary path: %v", err)
+	}
+
+	// Discovered in the working directory
+	cmd := exec.Command(binary, "scan", "config.json")
+	cmd.Dir = tmpDir
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("expected no_fail from the defaults file to exit 0: %v\n%s", err, output)
+	}
+	var report map[string]interface{}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("expected JSON output from the defaults file format, got: %v\n%s", err, output)
+	}
+
+	// A flag overrides the file
+	cmd = exec.Command(binary, "scan", "--format", "csv", "config.json")
+	cmd.Dir = tmpDir
+	output, _ = cmd.Output()
+	if !strings.HasPrefix(string(output), "file,") {
+		t.Errorf("expected --format csv to override the defaults file, got:\n%s", output)
+	}
+
+	// --config names a file elsewhere; a missing one is an error
+	otherDefaults := filepath.Join(tmpDir, "ci.yaml")
+	if err := os.WriteFile(otherDefaults, []byte("format: csv\nno_fail: true\n"), 0644); err != nil {
+		t.Fatalf("failed to write defaults file: %v", err)
+	}
+	output, err = exec.Command(binary, "scan", "--config", otherDefaults, configFile).Output()
+	if err != nil || !strings.HasPrefix(string(output), "file,") {
+		t.Errorf("expected CSV output from --config, got %v:\n%s", err, output)
+	}
+	if err := exec.Command(binary, "scan", "--config", filepath.Join(tmpDir, "missing.yaml"), configFile).Run(); err == nil {
+		t.Error("expected a missing --config file to fail")
+	}
+
+	// risk_weights changes the risk score; bad severities are rejected
+	rules := `
+version: "1.0.0"
+rules:
+  - id: TEMP_TEST
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, "rules.yaml"), []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	weighted := filepath.Join(tmpDir, "weighted.yaml")
+	if err := os.WriteFile(weighted, []byte("rules: [rules.yaml]\nformat: json\nno_fail: true\nrisk_weights:\n  high: 70\n"), 0644); err != nil {
+		t.Fatalf("failed to write defaults file: %v", err)
+	}
+	output, err = exec.Command(binary, "scan", "--config", weighted, configFile).Output()
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, output)
+	}
+	var scored struct {
+		Summary struct {
+			RiskScore int `json:"risk_score"`
+		} `json:"summary"`
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(output, &scored); err != nil {
+		t.Fatalf("failed to parse JSON output: %v", err)
+	}
+	if scored.Summary.RiskScore != 70 || len(scored.Results) != 1 || scored.Results[0].RiskScore != 70 {
+		t.Errorf("expected a risk score of 70 from risk_weights, got %+v", scored)
+	}
+
+	badWeights := filepath.Join(tmpDir, "bad-weights.yaml")
+	if err := os.WriteFile(badWeights, []byte("risk_weights:\n  URGENT: 10\n"), 0644); err != nil {
+		t.Fatalf("failed to write defaults file: %v", err)
+	}
+	if err := exec.Command(binary, "scan", "--config", badWeights, configFile).Run(); err == nil {
+		t.Error("expected an unknown risk_weights severity to fail")
+	}
+}
+
+// TestE2E_CategorySummary tests that per-category counts add up to the total
+// in the JSON and text summaries
+func TestE2E_CategorySummary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	configContent := `{
+		"temperature": 1.9,
+		"api_key": "sk-test1234567890abcdefghijklmnopqr",
+		"max_tokens": 100000
+	}`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--format", "json", configFile).Output()
+	var result struct {
+		Summary struct {
+			TotalFindings int            `json:"total_findings"`
+			ByCategory    map[string]int `json:"by_category"`
+		} `json:"summary"`
+	}
+	if err := json.Unmarshal(output, &result); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if result.Summary.TotalFindings == 0 {
+		t.Fatal("expected findings")
+	}
+	sum := 0
+	for _, count := range result.Summary.ByCategory {
+		sum += count
+	}
+	if sum != result.Summary.TotalFindings {
+		t.Errorf("by_category counts sum to %d, want %d: %v", sum, result.Summary.TotalFindings, result.Summary.ByCategory)
+	}
+	// Declared categories are listed even without findings
+	if _, ok := result.Summary.ByCategory["monitoring"]; !ok {
+		t.Errorf("expected every declared category in by_category, got %v", result.Summary.ByCategory)
+	}
+
+	output, _ = exec.Command(binary, "scan", "--by-category", "--color", "never", configFile).Output()
+	text := string(output)
+	_, breakdown, found := strings.Cut(text, "By category:\n")
+	if !found {
+		t.Fatalf("expected a category breakdown, got:\n%s", text)
+	}
+	sum = 0
+	for _, line := range strings.Split(strings.TrimSpace(breakdown), "\n") {
+		_, count, _ := strings.Cut(line, ": ")
+		n, err := strconv.Atoi(count)
+		if err != nil {
+			t.Fatalf("unexpected breakdown line %q", line)
+		}
+		sum += n
+	}
+	if !strings.Contains(text, fmt.Sprintf("Total findings: %d\n", sum)) {
+		t.Errorf("category counts sum to %d, which does not match the total:\n%s", sum, text)
+	}
+
+	output, err := exec.Command(binary, "rules", "list", "--list-categories").Output()
+	if err != nil {
+		t.Fatalf("rules list --list-categories failed: %v\n%s", err, output)
+	}
+	for _, category := range []string{"secrets", "parameters", "rate_limiting"} {
+		if !strings.Contains(string(output), category) {
+			t.Errorf("expected category %q in:\n%s", category, output)
+		}
+	}
+}
+
+// TestE2E_ExplainFindings tests that --explain-findings prints the offending
+// value and limit, and that the detail is hidden otherwise
+func TestE2E_ExplainFindings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.9}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	output, _ := exec.Command(binary, "scan", "--explain-findings", "--color", "never", configFile).Output()
+	if !strings.Contains(string(output), "Detail: temperature = 1.9 (max allowed 1)") {
+		t.Errorf("expected the numeric context in the output, got:\n%s", output)
+	}
+
+	output, _ = exec.Command(binary, "scan", "--color", "never", configFile).Output()
+	if strings.Contains(string(output), "Detail:") {
+		t.Errorf("expected no detail without --explain-findings, got:\n%s", output)
+	}
+}
+
+// TestE2E_FilesFrom tests that --files-from scans only the listed files with
+// supported extensions, reading the list from a file or stdin
+func TestE2E_FilesFrom(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"config.json":  `{"temperature": 0.5}`,
+		"settings.yml": "temperature: 0.5\n",
+		"README.md":    "# not a config\n",
+		"deploy.sh":    "echo hi\n",
+	}
+	var list strings.Builder
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+		list.WriteString(path + "\n\n")
+	}
+	listFile := filepath.Join(tmpDir, "changed.txt")
+	if err := os.WriteFile(listFile, []byte(list.String()), 0644); err != nil {
+		t.Fatalf("failed to write file list: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+
+	scanned := func(output []byte) []string {
+		t.Helper()
+		var report struct {
+			Results []struct {
+				File string `json:"file"`
+			} `json:"results"`
+		}
+		if err := json.Unmarshal(output, &report); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, output)
+		}
+		var names []string
+		for _, result := range report.Results {
+			names = append(names, filepath.Base(result.File))
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	output, _ := exec.Command(binary, "scan", "--format", "json", "--files-from", listFile).Output()
+	if got := strings.Join(scanned(output), ","); got != "config.json,settings.yml" {
+		t.Errorf("scanned %s, want config.json,settings.yml", got)
+	}
+
+	cmd := exec.Command(binary, "scan", "--format", "json", "--files-from", "-")
+	cmd.Stdin = strings.NewReader(list.String())
+	output, _ = cmd.Output()
+	if got := strings.Join(scanned(output), ","); got != "config.json,settings.yml" {
+		t.Errorf("scanned %s from stdin, want config.json,settings.yml", got)
+	}
+
+	// A list with nothing to scan is not an error
+	cmd = exec.Command(binary, "scan", "--files-from", "-")
+	cmd.Stdin = strings.NewReader(filepath.Join(tmpDir, "README.md") + "\n")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("expected exit 0 with no supported files, got %v:\n%s", err, output)
+	}
+
+	// ...and still writes the report CI expects to find
+	reportFile := filepath.Join(tmpDir, "report.json")
+	cmd = exec.Command(binary, "scan", "--format", "json", "--output", reportFile, "--files-from", "-")
+	cmd.Stdin = strings.NewReader("")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("expected exit 0 with an empty list, got %v:\n%s", err, output)
+	}
+	report, err := os.ReadFile(reportFile)
+	if err != nil {
+		t.Fatalf("expected an empty report to be written: %v", err)
+	}
+	if got := scanned(report); len(got) != 0 {
+		t.Errorf("expected a report with no files, got %v", got)
+	}
+}
+
+// TestE2E_FailFast tests that --fail-fast reports only the first finding of
+// the first vulnerable file and exits non-zero
+func TestE2E_FailFast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	first := filepath.Join(tmpDir, "first.json")
+	second := filepath.Join(tmpDir, "second.json")
+	for _, path := range []string{first, second} {
+		if err := os.WriteFile(path, []byte(`{"temperature": 1.9, "api_key": "sk-abcdefghijklmnopqrstuvwxyz"}`), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	binary := buildTestBinary(t)
+	cmd := exec.Command(binary, "scan", "--format", "json", "--fail-fast", first, second)
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected exit code 1, got %v", err)
+	}
+
+	var report struct {
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if len(report.Results) != 1 || filepath.Base(report.Results[0].File) != "first.json" {
+		t.Fatalf("expected only first.json to be reported, got %+v", report.Results)
+	}
+	if len(report.Results[0].Findings) != 1 {
+		t.Errorf("expected a single finding, got %d", len(report.Results[0].Findings))
+	}
+
+	// Without --fail-fast both files are reported with every finding
+	output, _ = exec.Command(binary, "scan", "--format", "json", first, second).Output()
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if len(report.Results) != 2 || len(report.Results[0].Findings) < 2 {
+		t.Errorf("expected full results without --fail-fast, got %d results", len(report.Results))
+	}
+}
+
+// TestE2E_Init tests that init writes rules files the scanner can load and
+// refuses to overwrite without --force
+func TestE2E_Init(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	binary, err := filepath.Abs(buildTestBinary(t))
+	if err != nil {
+		t.Fatalf("failed to resolve binary path: %v", err)
+	}
+	tmpDir := t.TempDir()
+
+	cmd := exec.Command(binary, "init")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("init failed: %v\n%s", err, output)
+	}
+	rulesPath := filepath.Join(tmpDir, "rules.yaml")
+	s, err := scanner.NewScanner(rulesPath)
+	if err != nil {
+		t.Fatalf("generated rules.yaml does not load: %v", err)
+	}
+	defaults, _ := scanner.NewScannerWithDefaults()
+	if len(s.Rules()) != len(defaults.Rules()) {
+		t.Errorf("expected the built-in rules, got %d rules", len(s.Rules()))
+	}
+
+	// An existing file is kept unless --force is given
+	cmd = exec.Command(binary, "init", "--minimal")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "--force") {
+		t.Errorf("expected init to refuse to overwrite, got %v:\n%s", err, output)
+	}
+	cmd = exec.Command(binary, "init", "--minimal", "--force")
+	cmd.Dir = tmpDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("init --force failed: %v\n%s", err, output)
+	}
+	s, err = scanner.NewScanner(rulesPath)
+	if err != nil {
+		t.Fatalf("minimal rules.yaml does not load: %v", err)
+	}
+	if len(s.Rules()) == 0 || len(s.Rules()) >= len(defaults.Rules()) {
+		t.Errorf("expected a small starter rule set, got %d rules", len(s.Rules()))
+	}
+
+	// The starter rules are used by scan from the same directory
+	if err := os.WriteFile(filepath.Join(tmpDir, "config.json"), []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cmd = exec.Command(binary, "scan", "config.json")
+	cmd.Dir = tmpDir
+	output, _ := cmd.Output()
+	if !strings.Contains(string(output), "CUSTOM_TEMP_001") {
+		t.Errorf("expected scan to use the generated rules, got:\n%s", output)
+	}
+}
+
+func TestE2E_SetSeverity(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"seed": 42}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	criticalSeed := func(args ...string) bool {
+		args = append([]string{"scan", "--format", "json", "--min-display-severity", "CRITICAL"}, args...)
+		output, _ := exec.Command(binary, append(args, configPath)...).Output()
+		var report struct {
+			Results []scanner.ScanResult `json:"results"`
+		}
+		if err := json.Unmarshal(output, &report); err != nil {
+			t.Fatalf("invalid JSON output: %v\n%s", err, output)
+		}
+		for _, finding := range report.Results[0].Findings {
+			if finding.RuleID == "SEED_001" && finding.Severity == "CRITICAL" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if criticalSeed() {
+		t.Fatal("expected SEED_001 to stay below CRITICAL without --set-severity")
+	}
+	if !criticalSeed("--set-severity", "SEED_001=critical") {
+		t.Error("expected --set-severity to report SEED_001 as CRITICAL")
+	}
+
+	cmd := exec.Command(binary, "scan", "--set-severity", "SEED_001=URGENT", configPath)
+	if output, err := cmd.CombinedOutput(); err == nil || !strings.Contains(string(output), "invalid --set-severity") {
+		t.Errorf("expected an invalid severity to be rejected, got %v: %s", err, output)
+	}
+}
+
+func TestE2E_RedactOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	secret := "sk-abcdefghijklmnopqrstuvwxyz0123"
+	rulesPath := filepath.Join(tmpDir, "rules.yaml")
+	rules := `version: "1.0"
+rules:
+  - id: SECRET_001
+    name: Hardcoded Key
+    severity: CRITICAL
+    category: secrets
+    description: A key is committed in the config
+    fields: [api_key]
+    check:
+      type: pattern_match
+      patterns: ["sk-[a-z0-9]{20,}"]
+      redact: false
+    recommendation: Move the key to a secret store
+    recommendation_template: "Rotate {{.Value}} and move it to a secret store"
+`
+	configPath := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(rulesPath, []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"api_key": "`+secret+`"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	for _, format := range []string{"text", "json", "csv", "github"} {
+		t.Run(format, func(t *testing.T) {
+			args := []string{"scan", "--rules", rulesPath, "--format", format, "--explain-findings", configPath}
+			output, _ := exec.Command(binary, args...).Output()
+			if !strings.Contains(string(output), secret) {
+				t.Fatalf("expected the unredacted report to show the key, got:\n%s", output)
+			}
+
+			output, _ = exec.Command(binary, append([]string{"scan", "--redact-output"}, args[1:]...)...).Output()
+			if strings.Contains(string(output), secret) {
+				t.Errorf("--redact-output leaked the full key:\n%s", output)
+			}
+			if !strings.Contains(string(output), "sk-…23") {
+				t.Errorf("expected the masked key sk-…23 in the report, got:\n%s", output)
+			}
+		})
+	}
+}
+
+func TestE2E_ParseErrorsAsFindings(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	broken := filepath.Join(tmpDir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"temperature": 0.5,`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	cmd := exec.Command(binary, "scan", "--format", "json", "--parse-errors-as-findings", "--set-severity", "PARSE_ERROR=MEDIUM", broken)
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected exit code 1 for the finding, got %v", err)
+	}
+
+	var report struct {
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	if len(report.Results) != 1 || report.Results[0].Error != "" || len(report.Results[0].Findings) != 1 {
+		t.Fatalf("expected one result with a single finding, got %+v", report.Results)
+	}
+	if finding := report.Results[0].Findings[0]; finding.RuleID != "PARSE_ERROR" || finding.Severity != "MEDIUM" {
+		t.Errorf("expected a MEDIUM PARSE_ERROR finding, got %+v", finding)
+	}
+}
+
+func TestE2E_OnlyChanged(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	binary, err := filepath.Abs(buildTestBinary(t))
+	if err != nil {
+		t.Fatalf("failed to resolve binary path: %v", err)
+	}
+
+	// Outside a repository the mode fails with a clear message
+	cmd := exec.Command(binary, "scan", "--only-changed")
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(os.Environ(), "GIT_CEILING_DIRECTORIES="+filepath.Dir(cmd.Dir))
+	output, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 {
+		t.Fatalf("expected exit code 2 outside a git repository, got %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "not inside a git repository") {
+		t.Errorf("expected a git repository error, got:\n%s", output)
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	git("init", "-q")
+	git("config", "user.email", "test@example.com")
+	git("config", "user.name", "test")
+	write("changed.json", `{"temperature": 0.5}`)
+	write("unchanged.json", `{"temperature": 1.9}`)
+	write("notes.txt", "first")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+
+	// Nothing has changed yet
+	cmd = exec.Command(binary, "scan", "--only-changed", "--base-ref", "HEAD")
+	cmd.Dir = repo
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("expected exit 0 with no changed files, got %v\n%s", err, output)
+	}
+	reportFile := filepath.Join(t.TempDir(), "report.json")
+	cmd = exec.Command(binary, "scan", "--format", "json", "--output", reportFile, "--only-changed", "--base-ref", "HEAD")
+	cmd.Dir = repo
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("expected exit 0 with no changed files, got %v\n%s", err, output)
+	}
+	if _, err := os.Stat(reportFile); err != nil {
+		t.Errorf("expected an empty report to be written: %v", err)
+	}
+
+	write("changed.json", `{"temperature": 1.8}`)
+	write("new.yaml", "temperature: 0.2\n")
+	write("notes.txt", "second")
+
+	cmd = exec.Command(binary, "scan", "--format", "json", "--no-fail", "--only-changed", "--base-ref", "HEAD")
+	cmd.Dir = repo
+	output, err = cmd.Output()
+	if err != nil {
+		t.Fatalf("scan failed: %v\n%s", err, output)
+	}
+	var report struct {
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, output)
+	}
+	var files []string
+	for _, result := range report.Results {
+		files = append(files, filepath.Base(result.File))
+	}
+	if strings.Join(files, ",") != "changed.json,new.yaml" {
+		t.Errorf("expected only the changed and untracked configs to be scanned, got %v", files)
+	}
+}
+
+func TestE2E_ExitCodes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rules := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: MEDIUM
+    category: reliability
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "usage error", args: []string{"--format"}, want: 2},
+		{name: "invalid threshold", args: []string{"--fail-on", "severe", configFile}, want: 2},
+		{name: "missing file", args: []string{filepath.Join(tmpDir, "missing.json")}, want: 2},
+		{name: "findings without threshold", args: []string{configFile}, want: 1},
+		{name: "findings at threshold", args: []string{"--fail-on", "medium", configFile}, want: 1},
+		{name: "findings below threshold", args: []string{"--fail-on", "high", configFile}, want: 3},
+		{name: "overridden code", args: []string{"--fail-on", "high", "--exit-code", "below_threshold=0", configFile}, want: 0},
+		{name: "no fail", args: []string{"--no-fail", configFile}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := append([]string{"scan", "--rules", rulesFile}, tt.args...)
+			err := exec.Command(binary, args...).Run()
+			code := 0
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				code = exitErr.ExitCode()
+			} else if err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("exit code = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+// TestE2E_NDJSONOutput tests that --format ndjson writes one result per line
+func TestE2E_NDJSONOutput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	var configFiles []string
+	for i, content := range []string{`{"temperature": 1.5}`, `{"temperature": 2.0}`, `{"temperature": `} {
+		configFile := filepath.Join(tmpDir, fmt.Sprintf("config%d.json", i))
+		if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		configFiles = append(configFiles, configFile)
+	}
+
+	binary := buildTestBinary(t)
+	args := append([]string{"scan", "--format", "ndjson", "--continue-on-error"}, configFiles...)
+	output, err := exec.Command(binary, args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 2 {
+		t.Errorf("expected exit code 2 for the unparseable file, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) != len(configFiles) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(configFiles), len(lines), output)
+	}
+	for i, line := range lines {
+		var result scanner.ScanResult
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatalf("line %d is not a JSON object: %v\n%s", i+1, err, line)
+		}
+		if result.File != configFiles[i] {
+			t.Errorf("line %d file = %q, want %q", i+1, result.File, configFiles[i])
+		}
+		if i < 2 && (result.Error != "" || len(result.Findings) == 0) {
+			t.Errorf("expected findings for %s, got error %q", result.File, result.Error)
+		}
+		if i == 2 && result.Error == "" {
+			t.Errorf("expected an error for %s", result.File)
+		}
+	}
+}
+
+// TestE2E_SeverityMap tests that mapped labels are reported and accepted as
+// thresholds
+func TestE2E_SeverityMap(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rules := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: CRITICAL
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TOKENS_001
+    name: "Large Max Tokens"
+    severity: LOW
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: max_tokens
+      max: 4096
+`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	hot := filepath.Join(tmpDir, "hot.json")
+	if err := os.WriteFile(hot, []byte(`{"temperature": 1.5, "max_tokens": 100}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	long := filepath.Join(tmpDir, "long.json")
+	if err := os.WriteFile(long, []byte(`{"temperature": 0.2, "max_tokens": 8192}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	run := func(args ...string) (string, int) {
+		args = append([]string{"scan", "--rules", rulesFile, "--severity-map", "CRITICAL=P1,LOW=P4"}, args...)
+		output, err := exec.Command(binary, args...).Output()
+		code := 0
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			code = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("failed to run: %v", err)
+		}
+		return string(output), code
+	}
+
+	output, code := run("--format", "json", hot)
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	var report struct {
+		Summary struct {
+			BySeverity map[string]int `json:"by_severity"`
+		} `json:"summary"`
+		Results []struct {
+			Findings []struct {
+				RuleID   string `json:"rule_id"`
+				Severity string `json:"severity"`
+			} `json:"findings"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(output), &report); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if len(report.Results) != 1 || len(report.Results[0].Findings) != 1 || report.Results[0].Findings[0].Severity != "P1" {
+		t.Errorf("expected one P1 finding, got %+v", report.Results)
+	}
+	if report.Summary.BySeverity["P1"] != 1 || report.Summary.BySeverity["HIGH"] != 0 {
+		t.Errorf("by_severity = %v, want P1 counted and HIGH kept", report.Summary.BySeverity)
+	}
+	if _, ok := report.Summary.BySeverity["CRITICAL"]; ok {
+		t.Errorf("by_severity still lists CRITICAL: %v", report.Summary.BySeverity)
+	}
+
+	output, _ = run("--color", "never", hot)
+	if !strings.Contains(output, "[P1] High Temperature") || !strings.Contains(output, "P1: 1") {
+		t.Errorf("expected the P1 label in text output, got:\n%s", output)
+	}
+
+	// The label orders as the severity it maps
+	if _, code := run("--fail-on", "P1", hot); code != 1 {
+		t.Errorf("--fail-on P1 with a P1 finding: exit code = %d, want 1", code)
+	}
+	if _, code := run("--fail-on", "p1", long); code != 3 {
+		t.Errorf("--fail-on P1 with only a P4 finding: exit code = %d, want 3", code)
+	}
+	if _, code := run("--fail-on", "HIGH", long); code != 3 {
+		t.Errorf("--fail-on HIGH with only a P4 finding: exit code = %d, want 3", code)
+	}
+	if _, code := run("--fail-on", "P9", hot); code != 2 {
+		t.Errorf("--fail-on P9: exit code = %d, want 2", code)
+	}
+	output, _ = run("--format", "csv", "--min-display-severity", "P1", hot, long)
+	if !strings.Contains(output, "TEMP_001,High Temperature,P1,") {
+		t.Errorf("expected the P1 label in CSV output, got:\n%s", output)
+	}
+}
+
+// TestE2E_RuleStats tests that --rule-stats counts, per rule, the files it
+// was evaluated against and matched
+func TestE2E_RuleStats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rules := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TOKENS_001
+    name: "Large Max Tokens"
+    severity: LOW
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: max_tokens
+      max: 4096
+`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+	hot := filepath.Join(tmpDir, "hot.json")
+	if err := os.WriteFile(hot, []byte(`{"temperature": 1.5, "max_tokens": 100}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cool := filepath.Join(tmpDir, "cool.json")
+	if err := os.WriteFile(cool, []byte(`{"temperature": 0.2, "max_tokens": 100}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	binary := buildTestBinary(t)
+	output, _ := exec.Command(binary, "scan", "--rules", rulesFile, "--format", "json", "--rule-stats", hot, cool).Output()
+
+	var report struct {
+		RuleStats map[string]struct {
+			Evaluated int `json:"evaluated"`
+			Matched   int `json:"matched"`
+		} `json:"rule_stats"`
+		Results []map[string]interface{} `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if stat := report.RuleStats["TEMP_001"]; stat.Evaluated != 2 || stat.Matched != 1 {
+		t.Errorf("TEMP_001 stats = %+v, want 2 evaluated, 1 matched", stat)
+	}
+	if stat := report.RuleStats["TOKENS_001"]; stat.Evaluated != 2 || stat.Matched != 0 {
+		t.Errorf("TOKENS_001 stats = %+v, want 2 evaluated, 0 matched", stat)
+	}
+	for _, result := range report.Results {
+		if _, ok := result["passed"]; ok {
+			t.Errorf("expected no passed list without --show-passed, got %v", result)
+		}
+	}
+
+	output, _ = exec.Command(binary, "scan", "--rules", rulesFile, "--format", "json", hot).Output()
+	if strings.Contains(string(output), "rule_stats") {
+		t.Errorf("expected no rule_stats without --rule-stats, got:\n%s", output)
+	}
+}
+
+// TestE2E_Archive tests scanning the configs inside a zip archive
+func TestE2E_Archive(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping e2e test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rules := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+	if err := os.WriteFile(rulesFile, []byte(rules), 0644); err != nil {
+		t.Fatalf("failed to write rules: %v", err)
+	}
+
+	archive := filepath.Join(tmpDir, "bundle.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"configs/vulnerable.json": `{"temperature": 1.8}`,
+		"configs/safe.yaml":       "temperature: 0.3\n",
+		"README.txt":              "not a config",
+	} {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	f.Close()
+
+	binary, err := filepath.Abs(buildTestBinary(t))
+	if err != nil {
+		t.Fatalf("failed to resolve binary path: %v", err)
+	}
+	cmd := exec.Command(binary, "scan", "--rules", rulesFile, "--format", "json", "bundle.zip")
+	cmd.Dir = tmpDir
+	output, err := cmd.Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Errorf("expected exit code 1 for the vulnerable config, got %v", err)
+	}
+
+	var report struct {
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	got := make(map[string]int)
+	for _, result := range report.Results {
+		if result.Error != "" {
+			t.Errorf("%s: unexpected error %s", result.File, result.Error)
+		}
+		got[result.File] = len(result.Findings)
+	}
+	want := map[string]int{"bundle.zip!configs/vulnerable.json": 1, "bundle.zip!configs/safe.yaml": 0}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings per file = %v, want %v", got, want)
+	}
+
+	// Member sources outlive the extracted copy for --diff-context
+	cmd = exec.Command(binary, "scan", "--rules", rulesFile, "--diff-context", "1", "bundle.zip")
+	cmd.Dir = tmpDir
+	output, _ = cmd.Output()
+	if !strings.Contains(string(output), `> 1 | {"temperature": 1.8}`) {
+		t.Errorf("expected the archive member's source line, got:\n%s", output)
+	}
+}
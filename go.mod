@@ -4,5 +4,9 @@ go 1.21
 
 require (
 	github.com/BurntSushi/toml v1.3.2
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/hashicorp/hcl v1.0.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+require golang.org/x/sys v0.13.0 // indirect
@@ -1,19 +1,43 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	texttemplate "text/template"
+	"time"
 
 	"github.com/aditya01933/paramguard/scanner"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
 )
 
 const version = "1.0.0"
 
+// defaultsFile is the scan defaults file looked up in the working directory
+const defaultsFile = ".paramguard.yaml"
+
 func main() {
 	if len(os.Args) < 2 {
 		printUsage()
-		os.Exit(1)
+		exitWithError()
 	}
 
 	command := os.Args[1]
@@ -21,6 +45,14 @@ func main() {
 	switch command {
 	case "scan":
 		runScan()
+	case "rules":
+		runRules()
+	case "diff":
+		runDiff()
+	case "explain":
+		runExplain()
+	case "init":
+		runInit()
 	case "version":
 		fmt.Printf("paramguard v%s\n", version)
 	case "help", "--help", "-h":
@@ -28,205 +60,3090 @@ func main() {
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		printUsage()
-		os.Exit(1)
+		exitWithError()
+	}
+}
+
+func runScan() {
+	args := os.Args[2:]
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No config files specified")
+		fmt.Fprintln(os.Stderr, "Usage: paramguard scan <config-file> [config-file...]")
+		exitWithError()
+	}
+
+	var rulesFiles []string
+	var outputFormat string
+	var outputFile string
+	var outputTemplatePath string
+	var configFiles []string
+	var parseOptions scanner.ParseOptions
+	continueOnError := false
+	minDisplaySeverity := ""
+	fix := false
+	inPlace := false
+	var tags []string
+	showPassed := false
+	relativeTo := ""
+	noFail := false
+	showStats := false
+	showRuleStats := false
+	colorMode := "auto"
+	quiet := false
+	byCategory := false
+	explainFindings := false
+	cachePath := ""
+	watch := false
+	filesFrom := ""
+	failFast := false
+	recursive := false
+	severityOverrides := make(map[string]string)
+	redactOutput := false
+	parseErrorsAsFindings := false
+	onlyChanged := false
+	baseRef := "origin/main"
+	failOn := ""
+	diffContext := 0
+	var labels severityLabels
+	scanComments := false
+
+	// Defaults from .paramguard.yaml (or --config) apply before flags, so
+	// any flag given on the command line wins
+	defaultsPath, explicit := defaultsFile, false
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--config" {
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --config requires a file path")
+				exitWithError()
+			}
+			defaultsPath, explicit = args[i+1], true
+		}
+	}
+	defaults, err := loadScanDefaults(defaultsPath, explicit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", defaultsPath, err)
+		exitWithError()
+	}
+
+	// A --preset replaces the matching defaults; flags still win
+	var preset *scanPreset
+	for i := 0; i < len(args); i++ {
+		if args[i] == "--preset" {
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: --preset requires a name (%s)\n", presetChoices)
+				exitWithError()
+			}
+			p, ok := scanPresets[strings.ToLower(args[i+1])]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: invalid --preset %q (use %s)\n", args[i+1], presetChoices)
+				exitWithError()
+			}
+			preset = &p
+		}
+	}
+	if preset != nil {
+		preset.apply(&defaults)
+	}
+	outputFormat = defaults.Format
+	outputFile = defaults.Output
+	minDisplaySeverity = strings.ToUpper(defaults.MinDisplaySeverity)
+	if defaults.Color != "" {
+		colorMode = defaults.Color
+	}
+	noFail = defaults.NoFail
+	continueOnError = defaults.ContinueOnError
+	failOn = strings.ToUpper(defaults.FailOn)
+	for outcome, code := range defaults.ExitCodes {
+		// Validated by loadScanDefaults
+		_ = codes.set(outcome, code)
+	}
+
+	// Parse flags
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--config", "--preset":
+			i++
+		case "--rules":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --rules requires a file or directory path")
+				exitWithError()
+			}
+			rulesFiles = append(rulesFiles, args[i+1])
+			i++
+		case "--format":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --format requires a value (text, json, ndjson, csv, github, html, or prometheus)")
+				exitWithError()
+			}
+			outputFormat = args[i+1]
+			i++
+		case "--output-template":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --output-template requires a template file path")
+				exitWithError()
+			}
+			outputTemplatePath = args[i+1]
+			i++
+		case "--output":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --output requires a file path (or - for stdout)")
+				exitWithError()
+			}
+			outputFile = args[i+1]
+			i++
+		case "--expand-env-keys":
+			parseOptions.ExpandEnvKeys = true
+		case "--strict-json":
+			parseOptions.StrictJSON = true
+		case "--tolerant":
+			parseOptions.TolerantJSON = true
+		case "--normalize-values":
+			parseOptions.NormalizeValues = true
+		case "--max-file-size":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --max-file-size requires a size (e.g. 10MB, 512KB, or bytes)")
+				exitWithError()
+			}
+			size, err := parseSize(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --max-file-size: %v\n", err)
+				exitWithError()
+			}
+			parseOptions.MaxFileSize = size
+			i++
+		case "--min-display-severity":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --min-display-severity requires a value (CRITICAL, HIGH, MEDIUM, or LOW)")
+				exitWithError()
+			}
+			// Checked once --severity-map labels are known
+			minDisplaySeverity = args[i+1]
+			i++
+		case "--tag":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --tag requires a value")
+				exitWithError()
+			}
+			tags = append(tags, args[i+1])
+			i++
+		case "--set-severity":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --set-severity requires RULE_ID=SEVERITY")
+				exitWithError()
+			}
+			ruleID, severity, err := parseSeverityOverride(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --set-severity: %v\n", err)
+				exitWithError()
+			}
+			severityOverrides[ruleID] = severity
+			i++
+		case "--show-passed":
+			showPassed = true
+		case "--stats":
+			showStats = true
+		case "--rule-stats":
+			showRuleStats = true
+		case "--quiet", "-q":
+			quiet = true
+		case "--by-category":
+			byCategory = true
+		case "--explain-findings":
+			explainFindings = true
+		case "--fail-fast":
+			failFast = true
+		case "--redact-output":
+			redactOutput = true
+		case "--color":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --color requires a value (never, always, or auto)")
+				exitWithError()
+			}
+			colorMode = args[i+1]
+			if colorMode != "never" && colorMode != "always" && colorMode != "auto" {
+				fmt.Fprintf(os.Stderr, "Error: invalid --color %q (use never, always, or auto)\n", colorMode)
+				exitWithError()
+			}
+			i++
+		case "--timeout":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --timeout requires a duration (e.g. 10s or 1m)")
+				exitWithError()
+			}
+			timeout, err := time.ParseDuration(args[i+1])
+			if err != nil || timeout <= 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid --timeout %q (use a duration such as 10s or 1m)\n", args[i+1])
+				exitWithError()
+			}
+			parseOptions.FetchTimeout = timeout
+			i++
+		case "--select":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --select requires a dotted path (e.g. services.llm)")
+				exitWithError()
+			}
+			parseOptions.Select = args[i+1]
+			i++
+		case "--unwrap-key":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --unwrap-key requires a top-level key name")
+				exitWithError()
+			}
+			parseOptions.UnwrapKey = args[i+1]
+			i++
+		case "--decode-base64-values":
+			parseOptions.DecodeBase64Values = true
+		case "--files-from":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --files-from requires a file path (or - for stdin)")
+				exitWithError()
+			}
+			filesFrom = args[i+1]
+			i++
+		case "--only-changed":
+			onlyChanged = true
+		case "--base-ref":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --base-ref requires a git ref (e.g. origin/main)")
+				exitWithError()
+			}
+			baseRef = args[i+1]
+			i++
+		case "--watch":
+			watch = true
+		case "--recursive", "-r":
+			recursive = true
+		case "--cache":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --cache requires a file path")
+				exitWithError()
+			}
+			cachePath = args[i+1]
+			i++
+		case "--relative-to":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --relative-to requires a directory")
+				exitWithError()
+			}
+			relativeTo = args[i+1]
+			i++
+		case "--fix":
+			fix = true
+		case "--in-place":
+			inPlace = true
+		case "--no-fail", "--exit-zero":
+			noFail = true
+		case "--diff-context":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --diff-context requires a number of lines")
+				exitWithError()
+			}
+			n, err := strconv.Atoi(args[i+1])
+			if err != nil || n < 0 {
+				fmt.Fprintf(os.Stderr, "Error: invalid --diff-context %q (use a number of lines, 0 or more)\n", args[i+1])
+				exitWithError()
+			}
+			diffContext = n
+			i++
+		case "--fail-on":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --fail-on requires a severity (CRITICAL, HIGH, MEDIUM, or LOW)")
+				exitWithError()
+			}
+			failOn = args[i+1]
+			i++
+		case "--severity-map":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --severity-map requires a file or SEVERITY=LABEL pairs (e.g. CRITICAL=P1,HIGH=P2)")
+				exitWithError()
+			}
+			labels, err = loadSeverityMap(args[i+1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --severity-map: %v\n", err)
+				exitWithError()
+			}
+			i++
+		case "--exit-code":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --exit-code requires OUTCOME=CODE (e.g. below_threshold=0)")
+				exitWithError()
+			}
+			if err := parseExitCode(args[i+1], &codes); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --exit-code: %v\n", err)
+				exitWithError()
+			}
+			i++
+		case "--parse-errors-as-findings":
+			parseErrorsAsFindings = true
+		case "--scan-comments":
+			scanComments = true
+		case "--continue-on-error":
+			continueOnError = true
+		case "--fail-on-error":
+			continueOnError = false
+		default:
+			configFiles = append(configFiles, args[i])
+		}
+	}
+
+	// An empty --files-from list or --only-changed set still writes a report
+	// (with no files) so CI steps that read it find one
+	nothingToScan := false
+	if filesFrom != "" {
+		listed, err := readFileList(filesFrom)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading --files-from: %v\n", err)
+			exitWithError()
+		}
+		// Hooks pass every changed file; an empty list is not an error
+		nothingToScan = len(configFiles) == 0 && len(listed) == 0
+		configFiles = append(configFiles, listed...)
+	}
+
+	if onlyChanged {
+		changed, err := changedConfigFiles(baseRef, configFiles)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --only-changed: %v\n", err)
+			exitWithError()
+		}
+		if len(changed) == 0 {
+			fmt.Fprintf(os.Stderr, "No config files changed since %s\n", baseRef)
+			nothingToScan = true
+		}
+		configFiles = changed
+	}
+
+	if len(configFiles) == 0 && !nothingToScan {
+		fmt.Fprintln(os.Stderr, "Error: No config files specified")
+		exitWithError()
+	}
+
+	// --rules and --tag on the command line replace the defaults file lists
+	if len(rulesFiles) == 0 {
+		rulesFiles = defaults.Rules
+	}
+	if len(tags) == 0 {
+		tags = defaults.Tags
+	}
+
+	// Thresholds may name a --severity-map label instead of a severity
+	if minDisplaySeverity != "" {
+		severity, ok := labels.resolve(minDisplaySeverity)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid severity %q (use %s)\n", minDisplaySeverity, labels.choices())
+			exitWithError()
+		}
+		minDisplaySeverity = severity
+	}
+	if failOn != "" {
+		severity, ok := labels.resolve(failOn)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid --fail-on severity %q (use %s)\n", failOn, labels.choices())
+			exitWithError()
+		}
+		failOn = severity
+	}
+
+	// Default format
+	if outputFormat == "" {
+		outputFormat = "text"
+	}
+
+	// Load the template before scanning so a broken one fails fast
+	var reportTemplate *texttemplate.Template
+	if outputTemplatePath != "" {
+		tmpl, err := loadOutputTemplate(outputTemplatePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading output template: %v\n", err)
+			exitWithError()
+		}
+		reportTemplate = tmpl
+	}
+
+	// Load rules
+	s, err := loadScanner(rulesFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		exitWithError()
+	}
+	s.ParseOptions = parseOptions
+	s.Tags = tags
+	// Rule stats count the passed rules as evaluated
+	s.RecordPassed = showPassed || showRuleStats
+	s.CollectStats = showStats
+	s.RiskWeights = riskWeights(defaults.RiskWeights)
+	s.FailFast = failFast
+	if preset != nil && preset.secretsSeverity != "" {
+		downgradeSecrets(s, severityOverrides, preset.secretsSeverity)
+	}
+	s.SeverityOverrides = severityOverrides
+	s.ParseErrorsAsFindings = parseErrorsAsFindings
+	// GitHub annotations point at the finding's line when it is known
+	s.ResolveLines = diffContext > 0 || outputFormat == "github"
+	s.ScanComments = scanComments
+
+	var cache *scanner.Cache
+	if cachePath != "" {
+		cache, err = scanner.LoadCache(cachePath, s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading cache: %v\n", err)
+			exitWithError()
+		}
+	}
+
+	// scanAll scans every config file, writes the report, and returns the
+	// results
+	scanAll := func() []scanner.ScanResult {
+		paths := configFiles
+		if recursive {
+			if paths, err = expandConfigPaths(configFiles); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				exitWithError()
+			}
+		}
+		allResults := make([]scanner.ScanResult, 0)
+
+		// Output results
+		out := io.Writer(os.Stdout)
+		target := os.Stdout
+		var outFile *os.File
+		if outputFile != "" && outputFile != "-" {
+			outFile, err = os.Create(outputFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+				exitWithError()
+			}
+			out = outFile
+			target = outFile
+		}
+
+		opts := outputOptions{
+			minSeverity: minDisplaySeverity,
+			showPassed:  showPassed,
+			plain:       usePlainOutput(colorMode, target),
+			quiet:       quiet,
+			byCategory:  byCategory,
+			categories:  s.Categories(),
+			explain:     explainFindings,
+			diffContext: diffContext,
+			labels:      labels,
+		}
+
+		if showRuleStats {
+			opts.ruleStats = make(map[string]*ruleStat)
+		}
+		if diffContext > 0 {
+			opts.sources = make(map[string][]string)
+		}
+
+		// ndjson writes each result as soon as its file is scanned and keeps
+		// only what the exit code needs
+		streaming := reportTemplate == nil && outputFormat == "ndjson"
+		record := func(result scanner.ScanResult) {
+			if opts.ruleStats != nil {
+				countRuleStats(opts.ruleStats, result)
+				if !showPassed {
+					result.Passed = nil
+				}
+			}
+			if !streaming {
+				allResults = append(allResults, result)
+				return
+			}
+			results := []scanner.ScanResult{result}
+			relativizePaths(results, relativeTo)
+			if redactOutput {
+				results = redactResults(results)
+			}
+			outputNDJSON(out, results[0], opts)
+			allResults = append(allResults, exitOutcome(result))
+		}
+
+		for _, configFile := range paths {
+			// Archive members are scanned from a temporary copy, so they
+			// bypass the cache and --fix
+			if isArchive(configFile) {
+				members, err := scanArchive(s, configFile, opts.sources)
+				if err != nil {
+					members = []scanner.ScanResult{{File: configFile, Findings: []scanner.Finding{}, Error: err.Error()}}
+				}
+				stop := false
+				for _, member := range members {
+					if member.Error != "" && !continueOnError {
+						fmt.Fprintf(os.Stderr, "Error scanning %s: %s\n", member.File, member.Error)
+						exitWithError()
+					}
+					record(member)
+					if failFast && len(member.Findings) > 0 {
+						stop = true
+						break
+					}
+				}
+				if stop {
+					break
+				}
+				continue
+			}
+
+			var result scanner.ScanResult
+			if cache != nil {
+				result, _, err = cache.Scan(s, configFile)
+			} else {
+				result, err = s.ScanFile(configFile)
+			}
+			if err != nil {
+				if !continueOnError {
+					fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", configFile, err)
+					exitWithError()
+				}
+				// Record the failure and keep going; the run still fails at the end
+				record(scanner.ScanResult{
+					File:     configFile,
+					Findings: []scanner.Finding{},
+					Error:    err.Error(),
+				})
+				continue
+			}
+			record(result)
+
+			if fix {
+				// A file that can't be fixed, such as a format that can't
+				// be written back, doesn't stop the scan
+				if err := fixFile(s, configFile, parseOptions, inPlace); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: skipping fix for %s: %v\n", configFile, err)
+				}
+			}
+			if failFast && len(result.Findings) > 0 {
+				break
+			}
+		}
+
+		if cache != nil {
+			if err := cache.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			}
+		}
+
+		if !streaming {
+			relativizePaths(allResults, relativeTo)
+			if redactOutput {
+				allResults = redactResults(allResults)
+			}
+		}
+
+		switch {
+		case streaming:
+			// Already written as each file was scanned
+		case reportTemplate != nil:
+			outputTemplate(out, reportTemplate, allResults, opts)
+		case outputFormat == "json":
+			outputJSON(out, allResults, opts)
+		case outputFormat == "csv":
+			outputCSV(out, allResults, opts)
+		case outputFormat == "github":
+			outputGitHub(out, allResults, opts)
+		case outputFormat == "html":
+			outputHTML(out, allResults, opts)
+		case outputFormat == "prometheus":
+			outputPrometheus(out, allResults, opts)
+		default:
+			outputText(out, allResults, opts)
+		}
+
+		if outFile != nil {
+			if err := outFile.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+				exitWithError()
+			}
+		}
+
+		if showStats {
+			printStats(os.Stderr, s.Stats())
+		}
+
+		return allResults
+	}
+
+	// There is nothing to watch; write the empty report and exit
+	if nothingToScan {
+		os.Exit(codes.forScan(scanAll(), failOn, noFail))
+	}
+
+	if watch {
+		if fix {
+			fmt.Fprintln(os.Stderr, "Error: --watch cannot be combined with --fix")
+			exitWithError()
+		}
+		// A file saved mid-edit may not parse; report it and keep watching
+		continueOnError = true
+		clearScreen := outputFile == "" && isTerminal(os.Stdout)
+		rescan := func() {
+			if clearScreen {
+				fmt.Print("\033[H\033[2J")
+			}
+			scanAll()
+			fmt.Fprintf(os.Stderr, "Watching %d path(s) for changes (Ctrl+C to stop)\n", len(configFiles))
+		}
+		rescan()
+		if err := watchPaths(nil, configFiles, recursive, watchDebounce, rescan); err != nil {
+			fmt.Fprintf(os.Stderr, "Error watching files: %v\n", err)
+			exitWithError()
+		}
+		return
+	}
+
+	os.Exit(codes.forScan(scanAll(), failOn, noFail))
+}
+
+// outputOptions controls what the report formatters include
+type outputOptions struct {
+	// minSeverity hides findings below this level from listings (not counts)
+	minSeverity string
+	// showPassed lists the rules each file passed
+	showPassed bool
+	// plain replaces emoji and box-drawing characters with ASCII
+	plain bool
+	// quiet prints only files with findings or errors, without a summary
+	quiet bool
+	// byCategory adds per-category counts to the text summary
+	byCategory bool
+	// categories orders the per-category counts in summaries
+	categories []string
+	// explain prints each finding's detail (offending value and limit)
+	explain bool
+	// diffContext prints this many source lines around each finding with a
+	// line number
+	diffContext int
+	// sources holds source lines captured during the scan, by absolute
+	// path, for files gone by output time such as archive members
+	sources map[string][]string
+	// labels renames severities in every format (--severity-map)
+	labels severityLabels
+	// ruleStats is added to JSON and template reports when set
+	// (--rule-stats)
+	ruleStats map[string]*ruleStat
+}
+
+// textStyle holds the decorations used by outputText
+type textStyle struct {
+	rule       string
+	fileIcon   string
+	summary    string
+	ok         string
+	failed     string
+	tip        string
+	references string
+	bullet     string
+	icons      map[string]string
+}
+
+var fancyStyle = textStyle{
+	rule:       strings.Repeat("━", 52),
+	fileIcon:   "📄 ",
+	summary:    "📊 SUMMARY",
+	ok:         "✓",
+	failed:     "✗",
+	tip:        "💡 ",
+	references: "📚 References:",
+	bullet:     "•",
+	icons: map[string]string{
+		"CRITICAL": "🔴 ",
+		"HIGH":     "🟠 ",
+		"MEDIUM":   "🟡 ",
+		"LOW":      "🔵 ",
+	},
+}
+
+var plainStyle = textStyle{
+	rule:       strings.Repeat("=", 52),
+	fileIcon:   "File: ",
+	summary:    "SUMMARY",
+	ok:         "[OK]",
+	failed:     "[ERROR]",
+	tip:        "Fix: ",
+	references: "References:",
+	bullet:     "-",
+	icons:      map[string]string{},
+}
+
+// usePlainOutput resolves a --color mode. "auto" is plain when NO_COLOR is
+// set or the output is not a terminal.
+func usePlainOutput(mode string, out *os.File) bool {
+	switch mode {
+	case "always":
+		return false
+	case "never":
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return true
+	}
+	return !isTerminal(out)
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printStats writes the slowest rules and files from a --stats run
+func printStats(w io.Writer, stats scanner.ScanStats) {
+	const maxRules, maxFiles = 10, 5
+
+	fmt.Fprintln(w, "\nSCAN STATISTICS")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RULE\tEVALUATIONS\tTOTAL\tAVERAGE")
+	for i, rule := range stats.Rules {
+		if i == maxRules {
+			break
+		}
+		avg := rule.Total / time.Duration(rule.Evaluations)
+		fmt.Fprintf(tw, "%s\t%d\t%v\t%v\n", rule.RuleID, rule.Evaluations, rule.Total, avg)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tDURATION")
+	for i, file := range stats.Files {
+		if i == maxFiles {
+			break
+		}
+		fmt.Fprintf(tw, "%s\t%v\n", file.File, file.Duration)
+	}
+	tw.Flush()
+}
+
+// loadScanner builds a scanner from the given rules paths. With no paths it
+// uses ./rules.yaml when present and the embedded default rules otherwise.
+// Keys a rule sets that its check type ignores are reported as warnings.
+func loadScanner(rulesFiles []string) (*scanner.Scanner, error) {
+	var s *scanner.Scanner
+	var err error
+	if len(rulesFiles) == 0 {
+		if _, statErr := os.Stat("rules.yaml"); statErr != nil {
+			s, err = scanner.NewScannerWithDefaults()
+		} else {
+			rulesFiles = []string{"rules.yaml"}
+		}
+	}
+	if len(rulesFiles) > 0 {
+		s, err = scanner.NewScannerFromFiles(rulesFiles)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, warning := range scanner.ValidateRules(s.Rules()) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return s, nil
+}
+
+// scanDefaults holds scan settings read from a defaults file
+type scanDefaults struct {
+	Rules              []string       `yaml:"rules"`
+	Format             string         `yaml:"format"`
+	Output             string         `yaml:"output"`
+	MinDisplaySeverity string         `yaml:"min_display_severity"`
+	Tags               []string       `yaml:"tags"`
+	Color              string         `yaml:"color"`
+	NoFail             bool           `yaml:"no_fail"`
+	ContinueOnError    bool           `yaml:"continue_on_error"`
+	RiskWeights        map[string]int `yaml:"risk_weights"`
+	FailOn             string         `yaml:"fail_on"`
+	ExitCodes          map[string]int `yaml:"exit_codes"`
+}
+
+// scanPreset is a built-in set of scan defaults selected with --preset.
+// Unlike the profiles in a rules file it applies to every scanned file.
+type scanPreset struct {
+	// failOn is the fail-on threshold; empty keeps the defaults file's
+	failOn string
+	// noFail reports findings without failing the scan
+	noFail bool
+	// allRules ignores the defaults file's tags so every rule runs
+	allRules bool
+	// secretsSeverity is the highest severity secrets findings are
+	// reported at; empty leaves them alone
+	secretsSeverity string
+}
+
+// scanPresets are the --preset choices by name
+var scanPresets = map[string]scanPreset{
+	// prod is strict: every rule runs and any finding fails the scan
+	"prod": {failOn: "LOW", allRules: true},
+	// dev is lenient: secrets, often placeholders in dev configs, drop to
+	// MEDIUM and only HIGH and CRITICAL findings fail the scan
+	"dev": {failOn: "HIGH", secretsSeverity: "MEDIUM"},
+	// test reports findings without ever failing the scan on them
+	"test": {noFail: true},
+}
+
+// presetChoices lists the --preset choices for error messages
+const presetChoices = "prod, dev, or test"
+
+// apply sets the preset's settings on defaults, before flags are parsed
+func (p scanPreset) apply(defaults *scanDefaults) {
+	if p.failOn != "" {
+		defaults.FailOn = p.failOn
+	}
+	defaults.NoFail = p.noFail
+	if p.allRules {
+		defaults.Tags = nil
+	}
+}
+
+// downgradeSecrets lowers rules in the secrets category, and commented-out
+// secrets, to severity. Rules already given a severity with --set-severity
+// and rules at or below severity are left alone.
+func downgradeSecrets(s *scanner.Scanner, overrides map[string]string, severity string) {
+	overridden := func(id string) bool {
+		for ruleID := range overrides {
+			if strings.EqualFold(ruleID, id) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rule := range s.Rules() {
+		if !strings.EqualFold(rule.Category, "secrets") || overridden(rule.ID) {
+			continue
+		}
+		if scanner.SeverityRank(rule.Severity) > scanner.SeverityRank(severity) {
+			overrides[rule.ID] = severity
+		}
+	}
+	if !overridden(scanner.CommentedSecretRuleID) {
+		overrides[scanner.CommentedSecretRuleID] = severity
+	}
+}
+
+// loadScanDefaults reads scan defaults from path. A missing file is only an
+// error when it was named explicitly. Relative rules paths are resolved
+// against the file's directory.
+func loadScanDefaults(path string, explicit bool) (scanDefaults, error) {
+	var defaults scanDefaults
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return defaults, nil
+		}
+		return defaults, err
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&defaults); err != nil && err != io.EOF {
+		return defaults, err
+	}
+
+	if defaults.MinDisplaySeverity != "" && !scanner.IsValidSeverity(defaults.MinDisplaySeverity) {
+		return defaults, fmt.Errorf("invalid min_display_severity %q (use CRITICAL, HIGH, MEDIUM, or LOW)", defaults.MinDisplaySeverity)
+	}
+	for severity, weight := range defaults.RiskWeights {
+		if !scanner.IsValidSeverity(severity) {
+			return defaults, fmt.Errorf("invalid risk_weights severity %q (use CRITICAL, HIGH, MEDIUM, or LOW)", severity)
+		}
+		if weight < 0 {
+			return defaults, fmt.Errorf("risk_weights %s must not be negative", severity)
+		}
+	}
+	if defaults.FailOn != "" && !scanner.IsValidSeverity(defaults.FailOn) {
+		return defaults, fmt.Errorf("invalid fail_on %q (use CRITICAL, HIGH, MEDIUM, or LOW)", defaults.FailOn)
+	}
+	check := defaultExitCodes
+	for outcome, code := range defaults.ExitCodes {
+		if err := check.set(outcome, code); err != nil {
+			return defaults, fmt.Errorf("exit_codes: %v", err)
+		}
+	}
+	if defaults.Color != "" && defaults.Color != "never" && defaults.Color != "always" && defaults.Color != "auto" {
+		return defaults, fmt.Errorf("invalid color %q (use never, always, or auto)", defaults.Color)
+	}
+	for i, rulesPath := range defaults.Rules {
+		if !filepath.IsAbs(rulesPath) {
+			defaults.Rules[i] = filepath.Join(filepath.Dir(path), rulesPath)
+		}
+	}
+	return defaults, nil
+}
+
+// parseSeverityOverride splits a --set-severity value of the form
+// RULE_ID=SEVERITY, returning the severity in upper case
+func parseSeverityOverride(value string) (string, string, error) {
+	ruleID, severity, ok := strings.Cut(value, "=")
+	ruleID = strings.TrimSpace(ruleID)
+	if !ok || ruleID == "" {
+		return "", "", fmt.Errorf("%q is not RULE_ID=SEVERITY", value)
+	}
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if !scanner.IsValidSeverity(severity) {
+		return "", "", fmt.Errorf("invalid severity %q for %s (use CRITICAL, HIGH, MEDIUM, or LOW)", severity, ruleID)
+	}
+	return ruleID, severity, nil
+}
+
+// severityLabels maps built-in severities to the labels reports show in
+// their place (--severity-map). Severities without a label keep their name,
+// and ordering always follows the built-in severities.
+type severityLabels map[string]string
+
+// loadSeverityMap reads a --severity-map value: either inline
+// SEVERITY=LABEL pairs separated by commas, or a YAML file mapping
+// severities to labels
+func loadSeverityMap(value string) (severityLabels, error) {
+	raw := make(map[string]string)
+	if strings.Contains(value, "=") {
+		for _, pair := range strings.Split(value, ",") {
+			severity, label, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("%q is not SEVERITY=LABEL", pair)
+			}
+			raw[severity] = label
+		}
+	} else {
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", value, err)
+		}
+	}
+
+	labels := make(severityLabels)
+	seen := make(map[string]string)
+	for severity, label := range raw {
+		severity = strings.ToUpper(strings.TrimSpace(severity))
+		label = strings.TrimSpace(label)
+		if !scanner.IsValidSeverity(severity) {
+			return nil, fmt.Errorf("invalid severity %q (use CRITICAL, HIGH, MEDIUM, or LOW)", severity)
+		}
+		if label == "" {
+			return nil, fmt.Errorf("empty label for %s", severity)
+		}
+		key := strings.ToUpper(label)
+		if other, ok := seen[key]; ok {
+			return nil, fmt.Errorf("label %q is used for both %s and %s", label, other, severity)
+		}
+		if scanner.IsValidSeverity(key) && key != severity {
+			return nil, fmt.Errorf("label %q for %s is another severity's name", label, severity)
+		}
+		seen[key] = severity
+		labels[severity] = label
+	}
+	return labels, nil
+}
+
+// label returns the name reports show for severity
+func (l severityLabels) label(severity string) string {
+	if label, ok := l[severity]; ok {
+		return label
+	}
+	return severity
+}
+
+// lower returns the label for severity, or the severity in lower case when
+// it has none, for formats that write severities in lower case
+func (l severityLabels) lower(severity string) string {
+	if label, ok := l[severity]; ok {
+		return label
+	}
+	return strings.ToLower(severity)
+}
+
+// resolve returns the built-in severity named by value, which may be a
+// label or a severity, ignoring case
+func (l severityLabels) resolve(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for severity, label := range l {
+		if strings.EqualFold(label, value) {
+			return severity, true
+		}
+	}
+	severity := strings.ToUpper(value)
+	return severity, scanner.IsValidSeverity(severity)
+}
+
+// choices lists the accepted threshold values for error messages
+func (l severityLabels) choices() string {
+	names := make([]string, len(scanner.Severities))
+	for i, severity := range scanner.Severities {
+		names[i] = l.label(severity)
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
+
+// exitCodes maps how a run ended to the process exit code
+type exitCodes struct {
+	Clean          int
+	Findings       int
+	Error          int
+	BelowThreshold int
+}
+
+// defaultExitCodes: 0 for a clean scan, 1 for findings at or above
+// --fail-on, 2 for a tool or usage error, and 3 for findings that are all
+// below --fail-on
+var defaultExitCodes = exitCodes{Clean: 0, Findings: 1, Error: 2, BelowThreshold: 3}
+
+// codes holds the exit codes in effect. scan applies exit_codes from the
+// defaults file and --exit-code on top of the defaults.
+var codes = defaultExitCodes
+
+// exitWithError ends the run after an error has been reported
+func exitWithError() {
+	os.Exit(codes.Error)
+}
+
+// set changes the exit code for outcome: clean, findings, error, or
+// below_threshold
+func (c *exitCodes) set(outcome string, code int) error {
+	if code < 0 || code > 125 {
+		return fmt.Errorf("exit code %d for %s must be between 0 and 125", code, outcome)
+	}
+	switch strings.ToLower(outcome) {
+	case "clean":
+		c.Clean = code
+	case "findings":
+		c.Findings = code
+	case "error":
+		c.Error = code
+	case "below_threshold":
+		c.BelowThreshold = code
+	default:
+		return fmt.Errorf("unknown outcome %q (use clean, findings, error, or below_threshold)", outcome)
+	}
+	return nil
+}
+
+// parseExitCode applies an --exit-code value of the form OUTCOME=CODE
+func parseExitCode(value string, c *exitCodes) error {
+	outcome, code, ok := strings.Cut(value, "=")
+	if !ok {
+		return fmt.Errorf("%q is not OUTCOME=CODE", value)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(code))
+	if err != nil {
+		return fmt.Errorf("invalid exit code %q for %s", code, outcome)
+	}
+	return c.set(strings.TrimSpace(outcome), n)
+}
+
+// forScan picks the exit code for a finished scan. A file that failed to
+// scan wins, then findings at or above failOn, then findings below it; an
+// empty failOn counts every finding as at the threshold. noFail exits clean
+// whatever was found.
+func (c exitCodes) forScan(results []scanner.ScanResult, failOn string, noFail bool) int {
+	hasErrors, atThreshold, belowThreshold := false, false, false
+	for _, result := range results {
+		if result.Error != "" {
+			hasErrors = true
+		}
+		for _, finding := range result.Findings {
+			if failOn == "" || scanner.SeverityRank(finding.Severity) >= scanner.SeverityRank(failOn) {
+				atThreshold = true
+			} else {
+				belowThreshold = true
+			}
+		}
+	}
+
+	switch {
+	case hasErrors:
+		return c.Error
+	case noFail:
+		return c.Clean
+	case atThreshold:
+		return c.Findings
+	case belowThreshold:
+		return c.BelowThreshold
+	}
+	return c.Clean
+}
+
+// parseSize parses a byte count with an optional KB, MB, or GB suffix
+// (powers of 1024). Zero disables the limit.
+func parseSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size", value)
+	}
+	if n == 0 {
+		return -1, nil
+	}
+	return n * multiplier, nil
+}
+
+// relativizePaths rewrites each result's file path relative to base (the
+// working directory when empty), keeping the absolute path alongside. Files
+// outside base keep the path they were given.
+func relativizePaths(results []scanner.ScanResult, base string) {
+	if base == "" {
+		base = "."
+	}
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return
+	}
+
+	for i := range results {
+		if scanner.IsRemoteSource(results[i].File) {
+			continue
+		}
+		abs, err := filepath.Abs(results[i].File)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(absBase, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		results[i].File = filepath.ToSlash(rel)
+		results[i].AbsolutePath = abs
+		refingerprint(&results[i])
+	}
+}
+
+// refingerprint recomputes the fingerprints of a result whose File was
+// renamed after scanning, so they match across checkouts. Findings are
+// copied first, since the originals may be cached.
+func refingerprint(result *scanner.ScanResult) {
+	findings := make([]scanner.Finding, len(result.Findings))
+	for i, finding := range result.Findings {
+		finding.FingerprintID = finding.Fingerprint(result.File)
+		findings[i] = finding
+	}
+	result.Findings = findings
+}
+
+// redactResults returns copies of results with every finding passed
+// through scanner.RedactFinding, leaving the originals (which may be cached)
+// untouched
+func redactResults(results []scanner.ScanResult) []scanner.ScanResult {
+	redacted := make([]scanner.ScanResult, len(results))
+	for i, result := range results {
+		findings := make([]scanner.Finding, len(result.Findings))
+		for j, finding := range result.Findings {
+			findings[j] = scanner.RedactFinding(finding)
+		}
+		result.Findings = findings
+		redacted[i] = result
+	}
+	return redacted
+}
+
+// readFileList reads newline-separated paths from a file, or from stdin
+// when source is "-". Blank lines and files whose extension paramguard
+// does not parse are skipped.
+func readFileList(source string) ([]string, error) {
+	in := io.Reader(os.Stdin)
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var files []string
+	lines := bufio.NewScanner(in)
+	for lines.Scan() {
+		path := strings.TrimSpace(lines.Text())
+		if path != "" && scanner.IsConfigFile(path) {
+			files = append(files, path)
+		}
+	}
+	return files, lines.Err()
+}
+
+// changedConfigFiles lists the config files in the working tree that differ
+// from baseRef, plus untracked ones, relative to the working directory and
+// sorted. Deleted files are left out. A non-empty scope keeps only files
+// that are, or are beneath, one of its paths.
+func changedConfigFiles(baseRef string, scope []string) ([]string, error) {
+	if _, err := gitLines("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, fmt.Errorf("not inside a git repository")
+	}
+	changed, err := gitLines("diff", "--name-only", "--relative", "--diff-filter=d", baseRef, "--")
+	if err != nil {
+		return nil, fmt.Errorf("git diff against %s failed: %v", baseRef, err)
+	}
+	untracked, err := gitLines("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("listing untracked files failed: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	var files []string
+	for _, file := range append(changed, untracked...) {
+		file = filepath.FromSlash(file)
+		if seen[file] || !scanner.IsConfigFile(file) || !inScope(file, scope) {
+			continue
+		}
+		seen[file] = true
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// gitLines runs git and returns the non-empty lines it prints, or git's
+// error message when it fails
+func gitLines(args ...string) ([]string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, errors.New(msg)
+		}
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// inScope reports whether path is one of scope's paths or lies beneath one.
+// An empty scope includes everything.
+func inScope(path string, scope []string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	path = filepath.Clean(path)
+	for _, dir := range scope {
+		dir = filepath.Clean(dir)
+		if dir == "." || path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandConfigPaths replaces each directory in paths with the config files
+// beneath it, in lexical order. Files are kept as given.
+func expandConfigPaths(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			expanded = append(expanded, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && scanner.IsConfigFile(file) {
+				expanded = append(expanded, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// Limits on what scanning one archive extracts, so a zip bomb cannot fill
+// the disk
+const (
+	maxArchiveEntries       = 10000
+	maxArchiveBytes   int64 = 100 << 20
+)
+
+// isArchive reports whether path names a .zip, .tar.gz, or .tgz archive
+func isArchive(path string) bool {
+	lower := strings.ToLower(path)
+	return !scanner.IsRemoteSource(path) &&
+		(strings.HasSuffix(lower, ".zip") || strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz"))
+}
+
+// scanArchive extracts the config files in an archive to a temporary
+// directory and scans each one, labelling results archive!path/inside. A
+// member that fails to scan is returned with its error; the error return is
+// for archives that cannot be read or break the extraction limits. The
+// directory is removed on return, so when sources is non-nil each member's
+// lines are saved in it under the label for --diff-context.
+func scanArchive(s *scanner.Scanner, archive string, sources map[string][]string) ([]scanner.ScanResult, error) {
+	dir, err := os.MkdirTemp("", "paramguard-archive-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	names, err := extractArchive(archive, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	results := make([]scanner.ScanResult, 0, len(names))
+	for _, name := range names {
+		label := archive + "!" + name
+		member := filepath.Join(dir, filepath.FromSlash(name))
+		result, err := s.ScanFile(member)
+		if err != nil {
+			results = append(results, scanner.ScanResult{File: label, Findings: []scanner.Finding{}, Error: err.Error()})
+			continue
+		}
+		result.File = label
+		refingerprint(&result)
+		if sources != nil {
+			sources[sourceKey(label)] = readLines(member)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// extractArchive writes the config files in a .zip, .tar.gz, or .tgz
+// archive under dir and returns their slash-separated paths inside it, in
+// archive order. Other entries are skipped. It fails on entries that would
+// escape dir, and on archives with more than maxArchiveEntries entries or
+// more than maxArchiveBytes of config files.
+func extractArchive(archive, dir string) ([]string, error) {
+	var names []string
+	seen := make(map[string]bool)
+	entries := 0
+	var total int64
+
+	extract := func(name string, regular bool, open func() (io.ReadCloser, error)) error {
+		entries++
+		if entries > maxArchiveEntries {
+			return fmt.Errorf("more than %d entries", maxArchiveEntries)
+		}
+		name = path.Clean(strings.TrimPrefix(name, "./"))
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("unsafe entry path %q", name)
+		}
+		if !regular || seen[name] || !scanner.IsConfigFile(name) {
+			return nil
+		}
+		seen[name] = true
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		in, err := open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		defer in.Close()
+		out, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		// Read one byte past the limit to tell a full archive from one
+		// that breaks it
+		n, err := io.Copy(out, io.LimitReader(in, maxArchiveBytes-total+1))
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if total > maxArchiveBytes {
+			return fmt.Errorf("config files exceed %d bytes uncompressed", maxArchiveBytes)
+		}
+		names = append(names, name)
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(archive), ".zip") {
+		reader, err := zip.OpenReader(archive)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		for _, f := range reader.File {
+			if err := extract(f.Name, f.Mode().IsRegular(), f.Open); err != nil {
+				return nil, err
+			}
+		}
+		return names, nil
+	}
+
+	file, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer compressed.Close()
+	reader := tar.NewReader(compressed)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		open := func() (io.ReadCloser, error) { return io.NopCloser(reader), nil }
+		if err := extract(header.Name, header.Typeflag == tar.TypeReg, open); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// watchDebounce is how long --watch waits after the last change before
+// re-scanning, so an editor's burst of writes triggers one scan
+const watchDebounce = 200 * time.Millisecond
+
+// watchPaths calls onChange once writes to the watched paths settle for
+// debounce. Files are watched through their parent directory so editors that
+// save by renaming a temp file are still seen. Directories are watched
+// recursively for config files when recursive is set. It runs until stop is
+// closed (a nil stop never closes) or the watcher fails.
+func watchPaths(stop <-chan struct{}, paths []string, recursive bool, debounce time.Duration, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	files := make(map[string]bool)
+	var roots []string
+	addTree := func(root string) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return watcher.Add(path)
+			}
+			return nil
+		})
+	}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if !recursive {
+				return fmt.Errorf("%s is a directory (use --recursive)", path)
+			}
+			roots = append(roots, abs)
+			if err := addTree(abs); err != nil {
+				return err
+			}
+			continue
+		}
+		files[abs] = true
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			return err
+		}
+	}
+
+	underRoot := func(name string) bool {
+		for _, root := range roots {
+			if rel, err := filepath.Rel(root, name); err == nil && !strings.HasPrefix(rel, "..") {
+				return true
+			}
+		}
+		return false
+	}
+
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-stop:
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(event.Name)
+			if event.Has(fsnotify.Create) && underRoot(name) {
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					addTree(name)
+				}
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			if files[name] || (underRoot(name) && scanner.IsConfigFile(name)) {
+				settled = time.After(debounce)
+			}
+		case <-settled:
+			settled = nil
+			onChange()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// isDisplayed reports whether a finding meets the --min-display-severity level
+func isDisplayed(finding scanner.Finding, minSeverity string) bool {
+	return minSeverity == "" || scanner.SeverityRank(finding.Severity) >= scanner.SeverityRank(minSeverity)
+}
+
+func runRules() {
+	args := os.Args[2:]
+	if len(args) == 1 && args[0] == "schema" {
+		runRulesSchema()
+		return
+	}
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintln(os.Stderr, "Usage: paramguard rules list [--rules <file>] [--format text|json] [--category <name>] [--severity <level>] [--list-categories]")
+		fmt.Fprintln(os.Stderr, "       paramguard rules schema")
+		exitWithError()
+	}
+
+	var rulesFiles []string
+	outputFormat := "text"
+	category := ""
+	severity := ""
+	listCategories := false
+
+	for i := 1; i < len(args); i++ {
+		switch args[i] {
+		case "--list-categories":
+			listCategories = true
+		case "--rules", "--format", "--category", "--severity":
+			if i+1 >= len(args) {
+				fmt.Fprintf(os.Stderr, "Error: %s requires a value\n", args[i])
+				exitWithError()
+			}
+			switch args[i] {
+			case "--rules":
+				rulesFiles = append(rulesFiles, args[i+1])
+			case "--format":
+				outputFormat = args[i+1]
+			case "--category":
+				category = args[i+1]
+			case "--severity":
+				severity = strings.ToUpper(args[i+1])
+			}
+			i++
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown option %s\n", args[i])
+			exitWithError()
+		}
+	}
+
+	s, err := loadScanner(rulesFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		exitWithError()
+	}
+
+	if listCategories {
+		printCategories(s, outputFormat)
+		return
+	}
+	if category != "" && !containsString(s.Categories(), category) {
+		fmt.Fprintf(os.Stderr, "Error: unknown category %q (known: %s)\n", category, strings.Join(s.Categories(), ", "))
+		exitWithError()
+	}
+
+	rules := []scanner.Rule{}
+	for _, rule := range s.Rules() {
+		if category != "" && rule.Category != category {
+			continue
+		}
+		if severity != "" && rule.Severity != severity {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	if outputFormat == "json" {
+		type ruleSummary struct {
+			ID       string   `json:"id"`
+			Name     string   `json:"name"`
+			Severity string   `json:"severity"`
+			Category string   `json:"category"`
+			Enabled  bool     `json:"enabled"`
+			Tags     []string `json:"tags,omitempty"`
+			CWE      string   `json:"cwe,omitempty"`
+		}
+		summaries := make([]ruleSummary, 0, len(rules))
+		for _, rule := range rules {
+			summaries = append(summaries, ruleSummary{
+				ID:       rule.ID,
+				Name:     rule.Name,
+				Severity: rule.Severity,
+				Category: rule.Category,
+				Enabled:  rule.IsEnabled(),
+				Tags:     rule.Tags,
+				CWE:      rule.CWE,
+			})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(summaries); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			exitWithError()
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSEVERITY\tCATEGORY\tNAME")
+	for _, rule := range rules {
+		name := rule.Name
+		if !rule.IsEnabled() {
+			name += " (disabled)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Category, name)
+	}
+	w.Flush()
+	fmt.Printf("\n%d rules\n", len(rules))
+}
+
+// printCategories lists the rule categories with the number of rules in each
+func printCategories(s *scanner.Scanner, outputFormat string) {
+	counts := make(map[string]int)
+	for _, rule := range s.Rules() {
+		counts[rule.Category]++
+	}
+
+	if outputFormat == "json" {
+		type categorySummary struct {
+			Name  string `json:"name"`
+			Rules int    `json:"rules"`
+		}
+		categories := []categorySummary{}
+		for _, category := range s.Categories() {
+			categories = append(categories, categorySummary{Name: category, Rules: counts[category]})
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(categories); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			exitWithError()
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CATEGORY\tRULES")
+	for _, category := range s.Categories() {
+		fmt.Fprintf(w, "%s\t%d\n", category, counts[category])
+	}
+	w.Flush()
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
+
+// runRulesSchema prints a JSON Schema for rules files, for editor validation
+// and autocompletion
+func runRulesSchema() {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(scanner.RulesSchema()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		exitWithError()
+	}
+}
+
+func runDiff() {
+	args := os.Args[2:]
+	outputFormat := "text"
+	showResolved := false
+	redactOutput := false
+	var files []string
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--format":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --format requires a value (text or json)")
+				exitWithError()
+			}
+			outputFormat = args[i+1]
+			i++
+		case "--show-resolved":
+			showResolved = true
+		case "--redact-output":
+			redactOutput = true
+		default:
+			files = append(files, args[i])
+		}
+	}
+
+	if len(files) != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: paramguard diff [--show-resolved] [--redact-output] [--format text|json] <base.json> <head.json>")
+		exitWithError()
+	}
+
+	base, err := loadReport(files[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", files[0], err)
+		exitWithError()
+	}
+	head, err := loadReport(files[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading %s: %v\n", files[1], err)
+		exitWithError()
+	}
+
+	if redactOutput {
+		base, head = redactResults(base), redactResults(head)
+	}
+	diff := scanner.DiffResults(base, head)
+
+	if outputFormat == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(diff); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			exitWithError()
+		}
+	} else {
+		fmt.Printf("New findings: %d\n", len(diff.Added))
+		for _, entry := range diff.Added {
+			printDiffEntry("+", entry)
+		}
+		if showResolved {
+			fmt.Printf("\nResolved findings: %d\n", len(diff.Removed))
+			for _, entry := range diff.Removed {
+				printDiffEntry("-", entry)
+			}
+		}
+		fmt.Printf("\n%d new, %d resolved, %d unchanged\n", len(diff.Added), len(diff.Removed), len(diff.Unchanged))
+	}
+
+	if len(diff.Added) > 0 {
+		os.Exit(codes.Findings)
+	}
+}
+
+// runExplain prints everything a rule checks and recommends, looked up by
+// ID in the loaded rules
+func runExplain() {
+	args := os.Args[2:]
+	var rulesFiles []string
+	ruleID := ""
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--rules":
+			if i+1 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --rules requires a file or directory path")
+				exitWithError()
+			}
+			rulesFiles = append(rulesFiles, args[i+1])
+			i++
+		default:
+			if ruleID != "" {
+				fmt.Fprintf(os.Stderr, "Error: unexpected argument %s\n", args[i])
+				exitWithError()
+			}
+			ruleID = args[i]
+		}
+	}
+
+	if ruleID == "" {
+		fmt.Fprintln(os.Stderr, "Usage: paramguard explain [--rules <path>] <RULE_ID>")
+		exitWithError()
+	}
+
+	s, err := loadScanner(rulesFiles)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
+		exitWithError()
+	}
+
+	for _, rule := range s.Rules() {
+		if strings.EqualFold(rule.ID, ruleID) {
+			explainRule(os.Stdout, rule)
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: no rule with ID %s\n", ruleID)
+	exitWithError()
+}
+
+// minimalRulesTemplate is the starter rules file written by init --minimal
+const minimalRulesTemplate = `# paramguard rules
+#
+# Each rule names a check to run against every scanned config. Run
+# "paramguard rules schema" for every supported key, and see scanner/rules.yaml
+# in the paramguard repository (or "paramguard init" without --minimal) for
+# the full built-in rule set.
+
+version: "1.0.0"
+
+# Categories group findings in summaries
+categories:
+  - secrets
+  - parameters
+  - rate_limiting
+
+rules:
+  # Flag values outside a numeric range
+  - id: CUSTOM_TEMP_001
+    name: "High Temperature"
+    severity: HIGH          # CRITICAL, HIGH, MEDIUM, or LOW
+    category: parameters
+    description: "Temperature above 1.0 makes outputs unpredictable."
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+    recommendation: "Use a temperature between 0.0 and 0.7 in production."
+    references:
+      - "Your team's LLM guidelines"
+
+  # Flag secrets by pattern in the listed fields
+  - id: CUSTOM_SECRET_001
+    name: "API Key in Config"
+    severity: CRITICAL
+    category: secrets
+    description: "A provider API key is stored in the config."
+    check:
+      type: pattern_match
+      patterns:
+        - "sk-[a-zA-Z0-9]{20,}"
+    fields:
+      - api_key
+    recommendation: "Load the key from the environment or a secret store."
+    references:
+      - "OWASP LLM02:2025 Sensitive Information Disclosure"
+
+  # Require a field to be present
+  - id: CUSTOM_RATE_001
+    name: "Missing Rate Limit"
+    severity: MEDIUM
+    category: rate_limiting
+    description: "No rate limit is configured."
+    check:
+      type: missing_field
+      field: rate_limit
+    recommendation: "Configure requests and tokens per minute."
+    references:
+      - "OWASP LLM10:2025 Unbounded Consumption"
+    # Optional: enabled: false skips the rule, tags: [...] selects it with
+    # --tag, and applies_when limits it to matching configs
+`
+
+func runInit() {
+	args := os.Args[2:]
+	force := false
+	minimal := false
+	path := "rules.yaml"
+	pathSet := false
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--force":
+			force = true
+		case "--minimal":
+			minimal = true
+		default:
+			if pathSet || strings.HasPrefix(args[i], "-") {
+				fmt.Fprintf(os.Stderr, "Error: unexpected argument %s\n", args[i])
+				fmt.Fprintln(os.Stderr, "Usage: paramguard init [--minimal] [--force] [path]")
+				exitWithError()
+			}
+			path = args[i]
+			pathSet = true
+		}
+	}
+
+	if _, err := os.Stat(path); err == nil && !force {
+		fmt.Fprintf(os.Stderr, "Error: %s already exists (use --force to overwrite)\n", path)
+		exitWithError()
+	}
+
+	content := scanner.DefaultRules()
+	if minimal {
+		content = []byte(minimalRulesTemplate)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+		exitWithError()
+	}
+
+	fmt.Printf("Wrote %s\n", path)
+	if filepath.Clean(path) == "rules.yaml" {
+		fmt.Println("paramguard scan uses it automatically when run from this directory.")
+	} else {
+		fmt.Printf("Scan with it using: paramguard scan --rules %s <config-file>\n", path)
+	}
+}
+
+// explainRule prints a readable description of a rule and its check
+func explainRule(w io.Writer, rule scanner.Rule) {
+	fmt.Fprintf(w, "%s - %s\n\n", rule.ID, rule.Name)
+	fmt.Fprintf(w, "Severity:  %s\n", rule.Severity)
+	fmt.Fprintf(w, "Category:  %s\n", rule.Category)
+	if rule.CWE != "" {
+		fmt.Fprintf(w, "CWE:       %s\n", rule.CWE)
+	}
+	if len(rule.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:      %s\n", strings.Join(rule.Tags, ", "))
+	}
+	if !rule.IsEnabled() {
+		fmt.Fprintln(w, "Status:    disabled")
+	}
+	if rule.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", rule.Description)
+	}
+
+	if len(rule.Checks) == 0 {
+		explainCheck(w, "Check", rule.Check, rule.Fields)
+	} else {
+		logic := "all must hold"
+		if strings.EqualFold(rule.Logic, "or") {
+			logic = "any may hold"
+		}
+		fmt.Fprintf(w, "\nChecks (%s):\n", logic)
+		for i, check := range rule.Checks {
+			explainCheck(w, fmt.Sprintf("%d. Check", i+1), check, rule.Fields)
+		}
+	}
+
+	if rule.Recommendation != "" {
+		fmt.Fprintf(w, "\n💡 %s\n", rule.Recommendation)
+	}
+	if len(rule.References) > 0 {
+		fmt.Fprintln(w, "\n📚 References:")
+		for _, ref := range rule.References {
+			fmt.Fprintf(w, "   • %s\n", ref)
+		}
+	}
+}
+
+// explainCheck prints one check's type and settings under heading
+func explainCheck(w io.Writer, heading string, check scanner.Check, ruleFields []string) {
+	fmt.Fprintf(w, "\n%s: %s\n", heading, check.Type)
+	detail := func(label string, value interface{}) {
+		fmt.Fprintf(w, "   %-15s %v\n", label+":", value)
+	}
+	list := func(label string, values []string) {
+		if len(values) > 0 {
+			detail(label, strings.Join(values, ", "))
+		}
+	}
+
+	if check.Parameter != "" {
+		detail("Parameter", check.Parameter)
+	}
+	list("Parameters", check.Parameters)
+	if check.Field != "" {
+		detail("Field", check.Field)
+	}
+	if check.Path != "" {
+		detail("Path", check.Path)
+	}
+	list("Fields", check.Fields)
+	list("Rule fields", ruleFields)
+	if check.Type == "numeric_range" || check.Min != 0 || check.Max != 0 {
+		detail("Min", check.Min)
+		detail("Max", check.Max)
+	}
+	if check.Condition != "" {
+		detail("Condition", check.Condition)
+	}
+	if len(check.Patterns) > 0 {
+		fmt.Fprintln(w, "   Patterns:")
+		for _, pattern := range check.Patterns {
+			fmt.Fprintf(w, "      • %s\n", pattern)
+		}
+	}
+	list("Flags", check.Flags)
+	if check.Anchored {
+		detail("Anchored", true)
+	}
+	if len(check.Conditions) > 0 {
+		require := check.Require
+		if check.RequireCount > 0 {
+			require = fmt.Sprintf("%s (%d)", require, check.RequireCount)
+		}
+		detail("Require", require)
+		fmt.Fprintln(w, "   Conditions:")
+		for i, condition := range check.Conditions {
+			if condition.Value == nil {
+				fmt.Fprintf(w, "      %d. %s %s\n", i+1, condition.Parameter, condition.Operator)
+			} else {
+				fmt.Fprintf(w, "      %d. %s %s %v\n", i+1, condition.Parameter, condition.Operator, condition.Value)
+			}
+		}
+	}
+	list("Has any", check.HasAny)
+	list("Missing all", check.MissingAll)
+	if check.Operator != "" {
+		detail("Operator", check.Operator)
+	}
+	if check.Value != nil {
+		detail("Value", check.Value)
+	}
+	if len(check.Values) > 0 {
+		detail("Values", check.Values)
+	}
+	if check.MaxSequences > 0 {
+		detail("Max sequences", check.MaxSequences)
+	}
+	if check.MaxLength > 0 {
+		detail("Max length", check.MaxLength)
+	}
+	if check.ExpectedType != "" {
+		detail("Expected type", check.ExpectedType)
+	}
+	if check.MinCount > 0 {
+		detail("Min count", check.MinCount)
+	}
+	if check.MaxCount > 0 {
+		detail("Max count", check.MaxCount)
+	}
+	if check.ReplacedBy != "" {
+		detail("Replaced by", check.ReplacedBy)
+	}
+	list("Allow", check.Allow)
+	list("Schemes", check.AllowedSchemes)
+	if check.BlockPrivateHosts {
+		detail("Block private", true)
+	}
+	if check.CaseInsensitive {
+		detail("Case-insensitive", true)
+	}
+	if check.Redact != nil && !*check.Redact {
+		detail("Redact", false)
 	}
 }
 
-func runScan() {
-	args := os.Args[2:]
-	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Error: No config files specified")
-		fmt.Fprintln(os.Stderr, "Usage: paramguard scan <config-file> [config-file...]")
-		os.Exit(1)
+// loadReport reads the results from a saved `scan --format json` report
+func loadReport(path string) ([]scanner.ScanResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Results []scanner.ScanResult `json:"results"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("not a paramguard JSON report: %w", err)
 	}
+	return report.Results, nil
+}
 
-	var rulesFile string
-	var outputFormat string
-	var configFiles []string
+func printDiffEntry(marker string, entry scanner.DiffEntry) {
+	f := entry.Finding
+	fmt.Printf("  %s %s: %s %s [%s]", marker, entry.File, f.RuleID, f.Name, f.Severity)
+	if f.Location != "" {
+		fmt.Printf(" at %s", f.Location)
+	}
+	fmt.Println()
+}
 
-	// Parse flags
-	for i := 0; i < len(args); i++ {
-		switch args[i] {
-		case "--rules":
-			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "Error: --rules requires a file path")
-				os.Exit(1)
-			}
-			rulesFile = args[i+1]
-			i++
-		case "--format":
-			if i+1 >= len(args) {
-				fmt.Fprintln(os.Stderr, "Error: --format requires a value (text or json)")
-				os.Exit(1)
-			}
-			outputFormat = args[i+1]
-			i++
-		default:
-			configFiles = append(configFiles, args[i])
-		}
+// fixFile clamps numeric_range violations in configFile and writes the
+// corrected config to <file>.fixed, or back to configFile when inPlace is set
+func fixFile(s *scanner.Scanner, configFile string, opts scanner.ParseOptions, inPlace bool) error {
+	if scanner.IsRemoteSource(configFile) {
+		return fmt.Errorf("remote configs cannot be fixed")
 	}
 
-	if len(configFiles) == 0 {
-		fmt.Fprintln(os.Stderr, "Error: No config files specified")
-		os.Exit(1)
+	config, err := scanner.ParseConfigFileWithOptions(configFile, opts)
+	if err != nil {
+		return err
 	}
 
-	// Default rules file
-	if rulesFile == "" {
-		rulesFile = "rules.yaml"
+	// Fixing the selected block changes the shared map, so the whole file is
+	// still written out
+	selected := config
+	if opts.Select != "" {
+		if selected, _, err = config.Select(opts.Select); err != nil {
+			return err
+		}
 	}
 
-	// Default format
-	if outputFormat == "" {
-		outputFormat = "text"
+	fixes := s.Fix(selected)
+	if len(fixes) == 0 {
+		return nil
 	}
 
-	// Load rules
-	s, err := scanner.NewScanner(rulesFile)
+	data, err := scanner.EncodeConfig(config)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading rules: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	// Scan all config files
-	allResults := make([]scanner.ScanResult, 0)
-	hasIssues := false
-
-	for _, configFile := range configFiles {
-		result, err := s.ScanFile(configFile)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error scanning %s: %v\n", configFile, err)
-			os.Exit(1)
-		}
-		allResults = append(allResults, result)
-		if len(result.Findings) > 0 {
-			hasIssues = true
-		}
+	target := configFile + ".fixed"
+	if inPlace {
+		target = configFile
 	}
 
-	// Output results
-	if outputFormat == "json" {
-		outputJSON(allResults)
-	} else {
-		outputText(allResults)
+	info, err := os.Stat(configFile)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, data, info.Mode().Perm()); err != nil {
+		return err
 	}
 
-	// Exit code
-	if hasIssues {
-		os.Exit(1)
+	for _, f := range fixes {
+		fmt.Fprintf(os.Stderr, "Fixed %s: %s %v -> %v\n", f.RuleID, f.Location, f.Old, f.New)
 	}
-	os.Exit(0)
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", target)
+	return nil
 }
 
-func outputText(results []scanner.ScanResult) {
+func outputText(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	style := fancyStyle
+	if opts.plain {
+		style = plainStyle
+	}
+	minSeverity := opts.minSeverity
 	totalFindings := 0
+	hiddenCount := 0
 	criticalCount := 0
 	highCount := 0
 	mediumCount := 0
 	lowCount := 0
+	errorCount := 0
+	categoryCounts := make(map[string]int)
+	sources := opts.sources
+	if sources == nil {
+		sources = make(map[string][]string)
+	}
 
 	for _, result := range results {
+		if result.Error != "" {
+			errorCount++
+			fmt.Fprintf(w, "%s %s - Error: %s\n", style.failed, result.File, result.Error)
+			continue
+		}
+
 		if len(result.Findings) == 0 {
-			fmt.Printf("✓ %s - No issues found\n", result.File)
+			if opts.quiet {
+				continue
+			}
+			fmt.Fprintf(w, "%s %s - No issues found\n", style.ok, result.File)
+			if opts.showPassed {
+				printPassed(w, result)
+			}
 			continue
 		}
 
-		fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-		fmt.Printf("📄 %s\n", result.File)
-		fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
+		displayed := 0
+		for _, finding := range result.Findings {
+			if isDisplayed(finding, minSeverity) {
+				displayed++
+			}
+		}
+		if displayed == 0 {
+			if opts.quiet {
+				continue
+			}
+			fmt.Fprintf(w, "%s %s - No issues at or above %s (%d hidden)\n", style.ok, result.File, opts.labels.label(minSeverity), len(result.Findings))
+		} else {
+			fmt.Fprintf(w, "\n%s\n", style.rule)
+			if result.Profile != "" {
+				fmt.Fprintf(w, "%s%s (profile: %s)\n", style.fileIcon, result.File, result.Profile)
+			} else {
+				fmt.Fprintf(w, "%s%s\n", style.fileIcon, result.File)
+			}
+			fmt.Fprintf(w, "%s\n", style.rule)
+		}
 
 		for _, finding := range result.Findings {
 			totalFindings++
+			categoryCounts[finding.Category]++
 
-			var icon string
 			switch finding.Severity {
 			case "CRITICAL":
-				icon = "🔴"
 				criticalCount++
 			case "HIGH":
-				icon = "🟠"
 				highCount++
 			case "MEDIUM":
-				icon = "🟡"
 				mediumCount++
 			case "LOW":
-				icon = "🔵"
 				lowCount++
 			}
 
-			fmt.Printf("\n%s %s [%s]\n", icon, finding.Name, finding.Severity)
-			fmt.Printf("   ID: %s\n", finding.RuleID)
-			fmt.Printf("   %s\n", finding.Description)
+			if !isDisplayed(finding, minSeverity) {
+				hiddenCount++
+				continue
+			}
+
+			label := opts.labels.label(finding.Severity)
+			if opts.plain {
+				fmt.Fprintf(w, "\n[%s] %s\n", label, finding.Name)
+			} else {
+				fmt.Fprintf(w, "\n%s%s [%s]\n", style.icons[finding.Severity], finding.Name, label)
+			}
+			if finding.CWE != "" {
+				fmt.Fprintf(w, "   ID: %s (%s)\n", finding.RuleID, finding.CWE)
+			} else {
+				fmt.Fprintf(w, "   ID: %s\n", finding.RuleID)
+			}
+			fmt.Fprintf(w, "   %s\n", finding.Description)
 
 			if finding.Location != "" {
-				fmt.Printf("   Location: %s\n", finding.Location)
+				if finding.Line > 0 {
+					fmt.Fprintf(w, "   Location: %s (line %d)\n", finding.Location, finding.Line)
+				} else {
+					fmt.Fprintf(w, "   Location: %s\n", finding.Location)
+				}
+			}
+			if opts.diffContext > 0 && finding.Line > 0 {
+				printSourceContext(w, sourceLines(sources, result), finding.Line, opts.diffContext)
+			}
+
+			if opts.explain && finding.Detail != "" {
+				fmt.Fprintf(w, "   Detail: %s\n", finding.Detail)
+			}
+
+			if finding.Evidence != "" {
+				fmt.Fprintf(w, "   Evidence: %s\n", finding.Evidence)
 			}
 
-			fmt.Printf("   💡 %s\n", finding.Recommendation)
+			fmt.Fprintf(w, "   %s%s\n", style.tip, finding.Recommendation)
 
 			if len(finding.References) > 0 {
-				fmt.Printf("   📚 References:\n")
+				fmt.Fprintf(w, "   %s\n", style.references)
 				for _, ref := range finding.References {
-					fmt.Printf("      • %s\n", ref)
+					fmt.Fprintf(w, "      %s %s\n", style.bullet, ref)
 				}
 			}
 		}
+
+		if opts.showPassed {
+			fmt.Fprintln(w)
+			printPassed(w, result)
+		}
+	}
+
+	if opts.quiet {
+		return
 	}
 
 	// Summary
-	fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("📊 SUMMARY\n")
-	fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
-	fmt.Printf("Total files scanned: %d\n", len(results))
-	fmt.Printf("Total findings: %d\n", totalFindings)
+	fmt.Fprintf(w, "\n%s\n", style.rule)
+	fmt.Fprintf(w, "%s\n", style.summary)
+	fmt.Fprintf(w, "%s\n", style.rule)
+	fmt.Fprintf(w, "Total files scanned: %d\n", len(results))
+	fmt.Fprintf(w, "Total findings: %d\n", totalFindings)
+	if errorCount > 0 {
+		fmt.Fprintf(w, "Files with errors: %d\n", errorCount)
+	}
+	// Mapped severities are listed under their label
+	severityName := func(severity, name string) string {
+		if label, ok := opts.labels[severity]; ok {
+			return label
+		}
+		return name
+	}
 	if criticalCount > 0 {
-		fmt.Printf("  🔴 Critical: %d\n", criticalCount)
+		fmt.Fprintf(w, "  %s%s: %d\n", style.icons["CRITICAL"], severityName("CRITICAL", "Critical"), criticalCount)
 	}
 	if highCount > 0 {
-		fmt.Printf("  🟠 High: %d\n", highCount)
+		fmt.Fprintf(w, "  %s%s: %d\n", style.icons["HIGH"], severityName("HIGH", "High"), highCount)
 	}
 	if mediumCount > 0 {
-		fmt.Printf("  🟡 Medium: %d\n", mediumCount)
+		fmt.Fprintf(w, "  %s%s: %d\n", style.icons["MEDIUM"], severityName("MEDIUM", "Medium"), mediumCount)
 	}
 	if lowCount > 0 {
-		fmt.Printf("  🔵 Low: %d\n", lowCount)
+		fmt.Fprintf(w, "  %s%s: %d\n", style.icons["LOW"], severityName("LOW", "Low"), lowCount)
 	}
-	fmt.Println()
+	if hiddenCount > 0 {
+		fmt.Fprintf(w, "Findings below %s not shown: %d\n", opts.labels.label(minSeverity), hiddenCount)
+	}
+	fmt.Fprintf(w, "Risk score: %d/%d\n", overallRiskScore(results), scanner.MaxRiskScore)
+	if opts.byCategory && totalFindings > 0 {
+		fmt.Fprintln(w, "By category:")
+		for _, category := range summaryCategories(opts.categories, categoryCounts) {
+			if categoryCounts[category] > 0 {
+				fmt.Fprintf(w, "  %s: %d\n", category, categoryCounts[category])
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// sourceLines returns the lines of a result's file, reading each file once
+// per report. A file that cannot be read has no lines.
+func sourceLines(sources map[string][]string, result scanner.ScanResult) []string {
+	path := result.AbsolutePath
+	if path == "" {
+		path = result.File
+	}
+	key := sourceKey(path)
+	if lines, ok := sources[key]; ok {
+		return lines
+	}
+	lines := readLines(path)
+	sources[key] = lines
+	return lines
+}
+
+// sourceKey returns the absolute form of a local path, so a file is found in
+// a sources map before and after relativizePaths
+func sourceKey(path string) string {
+	if scanner.IsRemoteSource(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// readLines returns the lines of a file, or none when it cannot be read
+func readLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// printSourceContext prints the numbered lines within context of line, like
+// a diff hunk, marking the line itself with ">". Secret-looking tokens are
+// masked as in --redact-output, since the lines are shown unredacted
+// otherwise.
+func printSourceContext(w io.Writer, lines []string, line, context int) {
+	if line > len(lines) {
+		return
+	}
+	start := max(1, line-context)
+	end := min(len(lines), line+context)
+	width := len(strconv.Itoa(end))
+	for n := start; n <= end; n++ {
+		marker := " "
+		if n == line {
+			marker = ">"
+		}
+		fmt.Fprintf(w, "   %s %*d | %s\n", marker, width, n, scanner.RedactSecrets(lines[n-1]))
+	}
+}
+
+// summaryCategories returns the known categories followed by any other
+// category that has findings, sorted, so summaries list every count
+func summaryCategories(known []string, counts map[string]int) []string {
+	categories := append([]string{}, known...)
+	var extra []string
+	for category := range counts {
+		if !containsString(known, category) {
+			extra = append(extra, category)
+		}
+	}
+	sort.Strings(extra)
+	return append(categories, extra...)
+}
+
+// jsonSummary carries the full finding counts, including findings filtered
+// out of the listing by --min-display-severity
+type jsonSummary struct {
+	TotalFiles     int            `json:"total_files"`
+	TotalFindings  int            `json:"total_findings"`
+	BySeverity     map[string]int `json:"by_severity"`
+	ByCategory     map[string]int `json:"by_category"`
+	RiskScore      int            `json:"risk_score"`
+	HiddenFindings int            `json:"hidden_findings,omitempty"`
+}
+
+// ruleStat counts the files a rule was evaluated against and the files it
+// reported at least one finding in
+type ruleStat struct {
+	Evaluated int `json:"evaluated"`
+	Matched   int `json:"matched"`
+}
+
+// countRuleStats adds one scanned file to stats. A rule was evaluated if it
+// fired or is listed as passed, so results must be scanned with
+// RecordPassed; rules skipped by applies_when or --tag are not counted.
+func countRuleStats(stats map[string]*ruleStat, result scanner.ScanResult) {
+	stat := func(ruleID string) *ruleStat {
+		if stats[ruleID] == nil {
+			stats[ruleID] = &ruleStat{}
+		}
+		return stats[ruleID]
+	}
+	matched := make(map[string]bool)
+	for _, finding := range result.Findings {
+		if !matched[finding.RuleID] {
+			matched[finding.RuleID] = true
+			stat(finding.RuleID).Evaluated++
+			stat(finding.RuleID).Matched++
+		}
+	}
+	for _, ruleID := range result.Passed {
+		stat(ruleID).Evaluated++
+	}
+}
+
+// overallRiskScore is the highest risk score of any scanned file, so one
+// risky config is not diluted by many clean ones
+func overallRiskScore(results []scanner.ScanResult) int {
+	score := 0
+	for _, result := range results {
+		if result.RiskScore > score {
+			score = result.RiskScore
+		}
+	}
+	return score
+}
+
+// riskWeights upper-cases the severities of configured risk weights
+func riskWeights(configured map[string]int) map[string]int {
+	if len(configured) == 0 {
+		return nil
+	}
+	weights := make(map[string]int, len(configured))
+	for severity, weight := range configured {
+		weights[strings.ToUpper(severity)] = weight
+	}
+	return weights
+}
+
+// printPassed lists the rules a file was checked against and passed
+func printPassed(w io.Writer, result scanner.ScanResult) {
+	if len(result.Passed) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "   Passed (%d): %s\n", len(result.Passed), strings.Join(result.Passed, ", "))
 }
 
-func outputJSON(results []scanner.ScanResult) {
+func outputJSON(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	summary, filtered := summarize(results, opts)
+
 	output := struct {
-		Version string               `json:"version"`
-		Results []scanner.ScanResult `json:"results"`
+		Version   string               `json:"version"`
+		ScannedAt string               `json:"scanned_at"`
+		Summary   *jsonSummary         `json:"summary"`
+		RuleStats map[string]*ruleStat `json:"rule_stats,omitempty"`
+		Results   []scanner.ScanResult `json:"results"`
 	}{
-		Version: version,
-		Results: results,
+		Version:   version,
+		ScannedAt: time.Now().UTC().Format(time.RFC3339),
+		Summary:   summary,
+		RuleStats: opts.ruleStats,
+		Results:   filtered,
 	}
 
-	encoder := json.NewEncoder(os.Stdout)
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	if err := encoder.Encode(output); err != nil {
 		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-		os.Exit(1)
+		exitWithError()
+	}
+}
+
+// outputNDJSON writes one result as a single line of JSON, holding only
+// the findings displayed at opts.minSeverity
+func outputNDJSON(w io.Writer, result scanner.ScanResult, opts outputOptions) {
+	_, filtered := summarize([]scanner.ScanResult{result}, opts)
+	if err := json.NewEncoder(w).Encode(filtered[0]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		exitWithError()
+	}
+}
+
+// exitOutcome strips a streamed result down to the error and finding
+// severities forScan looks at
+func exitOutcome(result scanner.ScanResult) scanner.ScanResult {
+	outcome := scanner.ScanResult{File: result.File, Error: result.Error}
+	for _, finding := range result.Findings {
+		outcome.Findings = append(outcome.Findings, scanner.Finding{Severity: finding.Severity})
+	}
+	return outcome
+}
+
+// summarize counts every finding in results and returns a copy of results
+// holding only the findings displayed at opts.minSeverity. Severities are
+// replaced by their opts.labels label in both.
+func summarize(results []scanner.ScanResult, opts outputOptions) (*jsonSummary, []scanner.ScanResult) {
+	summary := &jsonSummary{
+		TotalFiles: len(results),
+		BySeverity: make(map[string]int),
+		ByCategory: make(map[string]int),
+		RiskScore:  overallRiskScore(results),
+	}
+	for _, severity := range scanner.Severities {
+		summary.BySeverity[opts.labels.label(severity)] = 0
+	}
+	for _, category := range opts.categories {
+		summary.ByCategory[category] = 0
+	}
+
+	filtered := make([]scanner.ScanResult, len(results))
+	for i, result := range results {
+		filtered[i] = result
+		filtered[i].Findings = []scanner.Finding{}
+		for _, finding := range result.Findings {
+			summary.TotalFindings++
+			summary.ByCategory[finding.Category]++
+			displayed := isDisplayed(finding, opts.minSeverity)
+			finding.Severity = opts.labels.label(finding.Severity)
+			summary.BySeverity[finding.Severity]++
+			if displayed {
+				filtered[i].Findings = append(filtered[i].Findings, finding)
+			} else {
+				summary.HiddenFindings++
+			}
+		}
+	}
+	return summary, filtered
+}
+
+// templateReport is the data an --output-template is executed with
+type templateReport struct {
+	Version   string
+	ScannedAt string
+	Summary   *jsonSummary
+	RuleStats map[string]*ruleStat
+	Results   []scanner.ScanResult
+}
+
+// templateFuncs are the functions available to --output-template files
+var templateFuncs = texttemplate.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+}
+
+// loadOutputTemplate parses a user-supplied text/template file
+func loadOutputTemplate(path string) (*texttemplate.Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return texttemplate.New(filepath.Base(path)).Funcs(templateFuncs).Parse(string(data))
+}
+
+// outputTemplate renders results through a user-supplied template. Results
+// and the summary are filtered and counted as for --format json.
+func outputTemplate(w io.Writer, tmpl *texttemplate.Template, results []scanner.ScanResult, opts outputOptions) {
+	summary, filtered := summarize(results, opts)
+	report := templateReport{
+		Version:   version,
+		ScannedAt: time.Now().UTC().Format(time.RFC3339),
+		Summary:   summary,
+		RuleStats: opts.ruleStats,
+		Results:   filtered,
+	}
+	if err := tmpl.Execute(w, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering output template: %v\n", err)
+		exitWithError()
+	}
+}
+
+func outputCSV(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	writer := csv.NewWriter(w)
+
+	// Header is always written so empty reports still import cleanly
+	rows := [][]string{{"file", "rule_id", "name", "severity", "category", "location", "description", "recommendation"}}
+	for _, result := range results {
+		for _, finding := range result.Findings {
+			rows = append(rows, []string{
+				result.File,
+				finding.RuleID,
+				finding.Name,
+				opts.labels.label(finding.Severity),
+				finding.Category,
+				finding.Location,
+				finding.Description,
+				finding.Recommendation,
+			})
+		}
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing CSV: %v\n", err)
+		exitWithError()
+	}
+}
+
+// outputGitHub writes GitHub Actions workflow commands so findings show up as
+// annotations, and appends a Markdown job summary to $GITHUB_STEP_SUMMARY
+// when it is set
+func outputGitHub(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	var summary strings.Builder
+	summary.WriteString("## ParamGuard scan results\n\n")
+
+	counts := make(map[string]int)
+	var rows []string
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Fprintf(w, "::error file=%s,title=%s::%s\n",
+				escapeGitHubProperty(result.File), escapeGitHubProperty("Scan error"), escapeGitHubData(result.Error))
+			rows = append(rows, fmt.Sprintf("| ERROR | `%s` | | %s |", result.File, markdownCell(result.Error)))
+			continue
+		}
+
+		for _, finding := range result.Findings {
+			if !isDisplayed(finding, opts.minSeverity) {
+				continue
+			}
+			counts[finding.Severity]++
+
+			message := finding.Description
+			if finding.Location != "" {
+				message = fmt.Sprintf("%s (location: %s)", message, finding.Location)
+			}
+			if finding.Recommendation != "" {
+				message += "\n" + finding.Recommendation
+			}
+			file := escapeGitHubProperty(result.File)
+			if finding.Line > 0 {
+				file += fmt.Sprintf(",line=%d", finding.Line)
+			}
+			fmt.Fprintf(w, "::%s file=%s,title=%s::%s\n",
+				gitHubLevel(finding.Severity),
+				file,
+				escapeGitHubProperty(fmt.Sprintf("%s: %s", finding.RuleID, finding.Name)),
+				escapeGitHubData(message))
+			rows = append(rows, fmt.Sprintf("| %s | `%s` | %s | %s |",
+				markdownCell(opts.labels.label(finding.Severity)), result.File, finding.RuleID, markdownCell(finding.Name)))
+		}
+	}
+
+	summaryPath := os.Getenv("GITHUB_STEP_SUMMARY")
+	if summaryPath == "" {
+		return
+	}
+
+	fmt.Fprintf(&summary, "Scanned %d file(s): ", len(results))
+	parts := make([]string, 0, len(scanner.Severities))
+	for _, severity := range scanner.Severities {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[severity], opts.labels.lower(severity)))
+	}
+	summary.WriteString(strings.Join(parts, ", ") + "\n\n")
+	if len(rows) > 0 {
+		summary.WriteString("| Severity | File | Rule | Finding |\n|---|---|---|---|\n")
+		summary.WriteString(strings.Join(rows, "\n") + "\n")
+	} else {
+		summary.WriteString("No issues found.\n")
+	}
+
+	f, err := os.OpenFile(summaryPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing job summary: %v\n", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(summary.String()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing job summary: %v\n", err)
+	}
+}
+
+// htmlReport is the data rendered by htmlTemplate
+type htmlReport struct {
+	Version     string
+	TotalFiles  int
+	Severities  []htmlSeverityCount
+	Total       int
+	Hidden      int
+	MinSeverity string
+	RiskScore   int
+	MaxScore    int
+	Results     []htmlResult
+}
+
+// htmlSeverityCount and htmlFinding keep the built-in Severity for styling
+// next to the Label shown for it
+type htmlSeverityCount struct {
+	Severity string
+	Label    string
+	Count    int
+}
+
+type htmlResult struct {
+	scanner.ScanResult
+	Shown []htmlFinding
+}
+
+type htmlFinding struct {
+	scanner.Finding
+	Label string
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8"/>
+<title>ParamGuard report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.5em; }
+table.summary { border-collapse: collapse; margin-bottom: 1.5em; }
+table.summary td, table.summary th { border: 1px solid #d0d7de; padding: 0.3em 0.8em; text-align: left; }
+details { margin: 0.4em 0; }
+details.file { border: 1px solid #d0d7de; border-radius: 6px; padding: 0.5em 1em; }
+details.finding { border-left: 4px solid #8c959f; padding-left: 0.8em; }
+summary { cursor: pointer; }
+.badge { display: inline-block; min-width: 5.5em; padding: 0.1em 0.4em; border-radius: 4px; color: #fff; background: #8c959f; font-size: 0.8em; font-weight: bold; text-align: center; }
+.badge.critical { background: #a40e26; }
+.badge.high { background: #cf222e; }
+.badge.medium { background: #bf8700; }
+.badge.low { background: #0969da; }
+details.finding.critical { border-left-color: #a40e26; }
+details.finding.high { border-left-color: #cf222e; }
+details.finding.medium { border-left-color: #bf8700; }
+details.finding.low { border-left-color: #0969da; }
+.ok { color: #1a7f37; }
+.error { color: #cf222e; }
+code { background: #f6f8fa; padding: 0.1em 0.3em; border-radius: 4px; }
+</style>
+</head>
+<body>
+<h1>ParamGuard report</h1>
+<table class="summary">
+<tr><th>Files scanned</th><td>{{.TotalFiles}}</td></tr>
+<tr><th>Total findings</th><td>{{.Total}}</td></tr>
+{{- range .Severities}}
+<tr><th><span class="badge {{lower .Severity}}">{{.Label}}</span></th><td>{{.Count}}</td></tr>
+{{- end}}
+{{- if .Hidden}}
+<tr><th>Below {{.MinSeverity}} (not shown)</th><td>{{.Hidden}}</td></tr>
+{{- end}}
+<tr><th>Risk score</th><td>{{.RiskScore}}/{{.MaxScore}}</td></tr>
+</table>
+{{- range .Results}}
+<details class="file"{{if .Shown}} open="open"{{end}}>
+<summary><code>{{.File}}</code>
+{{- if .Error}} <span class="error">Error: {{.Error}}</span>
+{{- else if .Shown}} {{len .Shown}} finding(s){{if .Profile}} (profile: {{.Profile}}){{end}}, risk score {{.RiskScore}}
+{{- else}} <span class="ok">No issues found</span>{{end}}</summary>
+{{- range .Shown}}
+<details class="finding {{lower .Severity}}">
+<summary><span class="badge {{lower .Severity}}">{{.Label}}</span> {{.RuleID}}: {{.Name}}</summary>
+<p>{{.Description}}</p>
+<ul>
+{{- if .CWE}}
+<li>CWE: {{.CWE}}</li>
+{{- end}}
+{{- if .Location}}
+<li>Location: <code>{{.Location}}</code></li>
+{{- end}}
+{{- if .Detail}}
+<li>Detail: {{.Detail}}</li>
+{{- end}}
+{{- if .Evidence}}
+<li>Evidence: <code>{{.Evidence}}</code></li>
+{{- end}}
+{{- if .Recommendation}}
+<li>Recommendation: {{.Recommendation}}</li>
+{{- end}}
+</ul>
+{{- if .References}}
+<p>References:</p>
+<ul>
+{{- range .References}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</details>
+{{- end}}
+</details>
+{{- end}}
+<p><small>Generated by ParamGuard v{{.Version}}</small></p>
+</body>
+</html>
+`))
+
+// outputHTML writes a self-contained HTML page with a severity summary and a
+// collapsible section per file and per finding. Files with findings start
+// expanded. The page has no timestamp, so the same results always render the
+// same bytes.
+func outputHTML(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	report := htmlReport{
+		Version:     version,
+		TotalFiles:  len(results),
+		MinSeverity: opts.labels.label(opts.minSeverity),
+		RiskScore:   overallRiskScore(results),
+		MaxScore:    scanner.MaxRiskScore,
+	}
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		shown := []htmlFinding{}
+		for _, finding := range result.Findings {
+			report.Total++
+			counts[finding.Severity]++
+			if isDisplayed(finding, opts.minSeverity) {
+				shown = append(shown, htmlFinding{Finding: finding, Label: opts.labels.label(finding.Severity)})
+			} else {
+				report.Hidden++
+			}
+		}
+		report.Results = append(report.Results, htmlResult{ScanResult: result, Shown: shown})
+	}
+	for _, severity := range scanner.Severities {
+		report.Severities = append(report.Severities, htmlSeverityCount{Severity: severity, Label: opts.labels.label(severity), Count: counts[severity]})
+	}
+
+	if err := htmlTemplate.Execute(w, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing HTML: %v\n", err)
+		exitWithError()
+	}
+}
+
+// outputPrometheus writes the scan totals in the Prometheus text exposition
+// format, for a node_exporter textfile collector. Every finding is counted,
+// whatever --min-display-severity hides, and series are sorted so the output
+// is stable.
+func outputPrometheus(w io.Writer, results []scanner.ScanResult, opts outputOptions) {
+	bySeverity := make(map[string]int)
+	byRule := make(map[[2]string]int)
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+		for _, finding := range result.Findings {
+			bySeverity[finding.Severity]++
+			byRule[[2]string{finding.RuleID, finding.Severity}]++
+		}
+	}
+
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	metric("paramguard_files_scanned_total", "counter", "Config files scanned.")
+	fmt.Fprintf(&b, "paramguard_files_scanned_total %d\n", len(results))
+	metric("paramguard_files_failed_total", "counter", "Config files that could not be scanned.")
+	fmt.Fprintf(&b, "paramguard_files_failed_total %d\n", failed)
+
+	metric("paramguard_findings_total", "counter", "Findings by severity.")
+	for _, severity := range scanner.Severities {
+		fmt.Fprintf(&b, "paramguard_findings_total{severity=\"%s\"} %d\n", prometheusLabel(opts.labels.lower(severity)), bySeverity[severity])
+	}
+
+	metric("paramguard_rule_findings_total", "counter", "Findings by rule.")
+	keys := make([][2]string, 0, len(byRule))
+	for key := range byRule {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		fmt.Fprintf(&b, "paramguard_rule_findings_total{rule_id=\"%s\",severity=\"%s\"} %d\n",
+			prometheusLabel(key[0]), prometheusLabel(opts.labels.lower(key[1])), byRule[key])
+	}
+
+	metric("paramguard_risk_score", "gauge", "Highest risk score of any scanned file (0-100).")
+	fmt.Fprintf(&b, "paramguard_risk_score %d\n", overallRiskScore(results))
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing metrics: %v\n", err)
+		exitWithError()
+	}
+}
+
+var prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// prometheusLabel escapes a label value for the text exposition format
+func prometheusLabel(value string) string {
+	return prometheusLabelEscaper.Replace(value)
+}
+
+// gitHubLevel maps a severity to a workflow command
+func gitHubLevel(severity string) string {
+	switch severity {
+	case "CRITICAL", "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "notice"
 	}
 }
 
+var (
+	gitHubDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	gitHubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeGitHubData(value string) string {
+	return gitHubDataEscaper.Replace(value)
+}
+
+func escapeGitHubProperty(value string) string {
+	return gitHubPropertyEscaper.Replace(value)
+}
+
+// markdownCell keeps a value on one table row
+func markdownCell(value string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(value)
+}
+
 func printUsage() {
 	fmt.Println(`ParamGuard - LLM Configuration Security Scanner
 
 USAGE:
     paramguard scan [OPTIONS] <config-file> [config-file...]
+    paramguard rules list [--rules <file>] [--format json] [--category <name>] [--severity <level>]
+                          [--list-categories]
+    paramguard rules schema
+    paramguard diff [--show-resolved] [--redact-output] [--format json] <base.json> <head.json>
+    paramguard explain [--rules <path>] <RULE_ID>
+    paramguard init [--minimal] [--force] [path]
     paramguard version
     paramguard help
 
 COMMANDS:
     scan        Scan configuration files for security issues
+    rules list  List the loaded rules
+    rules schema
+                Print a JSON Schema for rules files
+    diff        Show findings in a head JSON report that are not in a base report
+    explain     Describe what a rule checks, its thresholds, and references
+    init        Write a starter rules file (default: rules.yaml) with the
+                built-in rules, or a small commented example with
+                --minimal; --force overwrites an existing file
     version     Print version information
     help        Print this help message
 
 OPTIONS:
-    --rules <file>      Path to custom rules file (default: rules.yaml)
-    --format <format>   Output format: text or json (default: text)
+    --rules <path>      Rules file or directory of .yaml/.yml files; repeat to
+                        merge several (default: rules.yaml, or the built-in
+                        rules when it does not exist)
+    --format <format>   Output format: text, json, ndjson, csv, github, html,
+                        or prometheus (default: text)
+    --output <file>     Write the report to a file instead of stdout (- for stdout)
+    --output-template <file>
+                        Render the report through a Go text/template file
+                        instead of --format
+    --expand-env-keys   Nest .env keys on "." and "__" (RATE_LIMIT__RPM -> rate_limit.rpm)
+    --tag <tag>         Only run rules with this tag (repeatable)
+    --min-display-severity <level>
+                        Only list findings at or above this severity; the
+                        summary still counts everything
+    --set-severity <RULE_ID=SEVERITY>
+                        Report a rule's findings at this severity instead of
+                        the one in the rules file (repeatable)
+    --show-passed       List the rules each file was checked against and passed
+    --quiet, -q         Text output lists only files with findings or errors
+                        and omits the summary
+    --by-category       Add per-category finding counts to the text summary
+    --explain-findings  Show the offending value and the limit it breaks for
+                        each finding (temperature = 1.9 (max allowed 1))
+    --stats             Print per-rule timings and the slowest files to stderr
+    --rule-stats        Add per-rule counts of files evaluated and matched to
+                        JSON output (rule_stats)
+    --color <mode>      Emoji and box drawing in text output: never, always, or
+                        auto (default; off when NO_COLOR is set or output is
+                        not a terminal)
+    --files-from <file> Also scan the paths listed one per line in this file
+                        (- for stdin), skipping unsupported file types
+    --only-changed      Scan only config files changed since --base-ref,
+                        including untracked ones; path arguments narrow the
+                        set to those files and directories
+    --base-ref <ref>    Git ref --only-changed compares against
+                        (default: origin/main)
+    --watch             Re-scan whenever a scanned file is saved, until
+                        interrupted
+    --recursive, -r     Scan (and with --watch, watch) the config files in
+                        any directory arguments
+    --cache <file>      Reuse results for files unchanged since the last scan
+                        with the same rules and options
+    --relative-to <dir> Report file paths relative to this directory
+                        (default: current directory)
+    --fix               Clamp numeric_range violations and write <file>.fixed
+                        (JSON, YAML, and TOML only)
+    --in-place          With --fix, overwrite the scanned file instead
+    --strict-json       Report duplicate keys in JSON files as findings
+    --tolerant          Allow comments and trailing commas in .json files
+    --normalize-values  Treat "true"/"false" and numeric strings as booleans
+                        and numbers (so .env behaves like YAML)
+    --max-file-size <size>
+                        Refuse config files larger than this (default: 10MB;
+                        0 disables the limit)
+    --timeout <duration>
+                        Time limit for fetching http(s) config URLs
+                        (default: 30s)
+    --select <path>     Scan only the block at this dotted path
+                        (e.g. services.llm) instead of the whole file
+    --unwrap-key <key>  Scan the configs embedded as strings under this
+                        top-level key instead of the file itself
+                        (Kubernetes ConfigMaps and Secrets are unwrapped
+                        automatically)
+    --decode-base64-values
+                        Also scan configs hidden in base64-encoded string
+                        values
+    --fail-fast         Stop at the first finding: report only it and skip
+                        the remaining rules and files
+    --redact-output     Mask evidence and anything secret-looking in
+                        locations, details, and recommendations (also
+                        accepted by diff)
+    --continue-on-error Report unparseable files and keep scanning the rest
+    --parse-errors-as-findings
+                        Report unparseable files as PARSE_ERROR findings
+                        (HIGH; change with --set-severity PARSE_ERROR=...)
+    --fail-on-error     Stop at the first unparseable file (default)
+    --scan-comments     Also search raw file content for provider API keys
+                        outside parsed values, e.g. in comments, reported as
+                        COMMENTED_SECRET with a line number
+    --no-fail           Exit 0 even when findings are reported (alias:
+                        --exit-zero); errors still exit 2
+    --diff-context <n>  Show n lines of the source around each finding, with
+                        the finding's line marked (JSON gains a "line")
+    --fail-on <level>   Exit 1 only for findings at or above this severity;
+                        findings that are all below it exit 3
+    --severity-map <file|SEVERITY=LABEL,...>
+                        Report severities under custom labels (e.g.
+                        CRITICAL=P1,HIGH=P2); thresholds accept the labels
+    --exit-code <outcome=code>
+                        Change the exit code for clean, findings, error, or
+                        below_threshold (repeatable)
+    --config <file>     Read scan defaults from this file instead of
+                        .paramguard.yaml in the working directory; flags
+                        override its values
+    --preset <name>     Apply a preset over the defaults file: prod (every
+                        rule, fail on LOW), dev (secrets reported at MEDIUM,
+                        fail on HIGH), or test (report only, never fail);
+                        flags override it. Unrelated to the profiles in a
+                        rules file, which match files by name
 
 EXAMPLES:
     # Scan a single config file
@@ -235,19 +3152,37 @@ EXAMPLES:
     # Scan multiple files
     paramguard scan config.json settings.yaml .env
 
+    # Scan every config in a .zip, .tar.gz, or .tgz bundle
+    paramguard scan configs.zip
+
     # Use custom rules
     paramguard scan --rules custom-rules.yaml config.json
 
+    # Merge rules split across files or a directory
+    paramguard scan --rules rules/ --rules extra.yaml config.json
+
     # JSON output for CI/CD
     paramguard scan --format json config.json
 
+    # CSV output for spreadsheets
+    paramguard scan --format csv config.json > findings.csv
+
+    # Report findings in a test pipeline without failing it
+    paramguard scan --preset test config.json
+
 EXIT CODES:
-    0    No security issues found
-    1    Security issues found or error occurred
+    0    No security issues found (or --no-fail was given)
+    1    Findings at or above --fail-on (any finding without it)
+    2    Tool or usage error, or a file that could not be read or parsed
+    3    Findings present, but all below --fail-on
+    Change any of them with --exit-code <outcome>=<code>, where outcome is
+    clean, findings, error, or below_threshold
 
 SUPPORTED FORMATS:
-    - JSON (.json)
+    - JSON (.json; .jsonc and .json5 allow comments and trailing commas)
     - YAML (.yaml, .yml)
     - TOML (.toml)
-    - Environment files (.env)`)
+    - HCL (.hcl, .tf)
+    - Environment files (.env)
+    - INI (.ini) and Java properties (.properties)`)
 }
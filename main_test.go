@@ -0,0 +1,400 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aditya01933/paramguard/scanner"
+)
+
+func TestWatchPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	configFile := filepath.Join(tmpDir, "config.json")
+	otherFile := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	changes := make(chan struct{}, 10)
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- watchPaths(stop, []string{configFile}, false, 50*time.Millisecond, func() { changes <- struct{}{} })
+	}()
+	defer func() {
+		close(stop)
+		if err := <-done; err != nil {
+			t.Errorf("watchPaths: %v", err)
+		}
+	}()
+
+	// Give the watcher time to register before writing
+	time.Sleep(100 * time.Millisecond)
+
+	// Unrelated files in the same directory are ignored
+	if err := os.WriteFile(otherFile, []byte("hello"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	select {
+	case <-changes:
+		t.Fatal("expected no re-scan for an unwatched file")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// A burst of writes to the watched file triggers a single re-scan
+	for i := 0; i < 3; i++ {
+		if err := os.WriteFile(configFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected modifying the watched file to trigger a re-scan")
+	}
+	select {
+	case <-changes:
+		t.Error("expected rapid writes to be debounced into one re-scan")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestExpandConfigPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"a.json", "nested/b.yaml", "nested/c.env.gz", "README.md"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	paths, err := expandConfigPaths([]string{tmpDir, "other.toml"})
+	if err != nil {
+		t.Fatalf("expandConfigPaths: %v", err)
+	}
+	want := []string{
+		filepath.Join(tmpDir, "a.json"),
+		filepath.Join(tmpDir, "nested/b.yaml"),
+		filepath.Join(tmpDir, "nested/c.env.gz"),
+		"other.toml",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("expandConfigPaths() = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
+
+func TestOutputHTML(t *testing.T) {
+	results := []scanner.ScanResult{
+		{
+			File:      "configs/app.json",
+			RiskScore: 60,
+			Findings: []scanner.Finding{
+				{RuleID: "TEMP_001", Name: "High Temperature", Severity: "CRITICAL", Category: "parameters", Location: "temperature", Recommendation: "Lower it"},
+				{RuleID: "SECRETS_001", Name: "Key <script>alert(1)</script>", Severity: "HIGH", Category: "secrets", Evidence: "sk-proj…901", References: []string{"A & B"}},
+			},
+		},
+		{File: "configs/clean.yaml", Findings: []scanner.Finding{}},
+		{File: "configs/broken.toml", Findings: []scanner.Finding{}, Error: "failed to parse"},
+	}
+
+	var first, second bytes.Buffer
+	outputHTML(&first, results, outputOptions{})
+	outputHTML(&second, results, outputOptions{})
+	if first.String() != second.String() {
+		t.Error("expected identical output for identical results")
+	}
+
+	// The page is well-formed markup, with user-controlled text escaped
+	decoder := xml.NewDecoder(bytes.NewReader(first.Bytes()))
+	decoder.Strict = true
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("HTML does not parse: %v\n%s", err, first.String())
+		}
+	}
+
+	page := first.String()
+	for _, want := range []string{"TEMP_001", "SECRETS_001", "configs/clean.yaml", "failed to parse", "&lt;script&gt;"} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected the page to contain %q", want)
+		}
+	}
+	if strings.Contains(page, "<script>") {
+		t.Error("expected finding text to be escaped")
+	}
+}
+
+func TestOutputPrometheus(t *testing.T) {
+	results := []scanner.ScanResult{
+		{File: "a.json", Findings: []scanner.Finding{
+			{RuleID: "TEMP_001", Severity: "HIGH"},
+			{RuleID: "SECRETS_001", Severity: "CRITICAL"},
+			{RuleID: "SECRETS_001", Severity: "CRITICAL"},
+		}},
+		{File: "b.yaml", Findings: []scanner.Finding{{RuleID: "TEMP_001", Severity: "HIGH"}}},
+		{File: "c.toml", Findings: []scanner.Finding{}, Error: "failed to parse"},
+	}
+
+	var out bytes.Buffer
+	outputPrometheus(&out, results, outputOptions{})
+
+	sample := regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*"(?:,[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*")*\})? (-?[0-9]+)$`)
+	values := make(map[string]string)
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		if strings.HasPrefix(line, "# HELP ") || strings.HasPrefix(line, "# TYPE ") {
+			continue
+		}
+		m := sample.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("invalid metric line %q", line)
+		}
+		values[m[1]+m[2]] = m[3]
+	}
+
+	want := map[string]string{
+		`paramguard_files_scanned_total`:                                            "3",
+		`paramguard_files_failed_total`:                                             "1",
+		`paramguard_findings_total{severity="critical"}`:                            "2",
+		`paramguard_findings_total{severity="high"}`:                                "2",
+		`paramguard_findings_total{severity="medium"}`:                              "0",
+		`paramguard_findings_total{severity="low"}`:                                 "0",
+		`paramguard_rule_findings_total{rule_id="SECRETS_001",severity="critical"}`: "2",
+		`paramguard_rule_findings_total{rule_id="TEMP_001",severity="high"}`:        "2",
+	}
+	for series, value := range want {
+		if values[series] != value {
+			t.Errorf("%s = %q, want %q", series, values[series], value)
+		}
+	}
+}
+
+func TestOutputTemplate(t *testing.T) {
+	results := []scanner.ScanResult{
+		{File: "a.json", Findings: []scanner.Finding{
+			{RuleID: "TEMP_001", Severity: "HIGH"},
+			{RuleID: "SECRETS_001", Severity: "CRITICAL"},
+		}},
+		{File: "b.yaml", Findings: []scanner.Finding{{RuleID: "STOP_001", Severity: "LOW"}}},
+	}
+
+	path := filepath.Join(t.TempDir(), "report.tmpl")
+	content := `{{.Summary.TotalFindings}} findings{{range .Results}} {{.File}}={{len .Findings}}{{end}} {{index .Summary.BySeverity "CRITICAL" | printf "%d" | lower}}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	tmpl, err := loadOutputTemplate(path)
+	if err != nil {
+		t.Fatalf("failed to load template: %v", err)
+	}
+
+	var out bytes.Buffer
+	outputTemplate(&out, tmpl, results, outputOptions{minSeverity: "MEDIUM"})
+	if want := "3 findings a.json=2 b.yaml=0 1"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	if _, err := loadOutputTemplate(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestExitCodesForScan(t *testing.T) {
+	clean := scanner.ScanResult{File: "clean.json", Findings: []scanner.Finding{}}
+	low := scanner.ScanResult{File: "low.json", Findings: []scanner.Finding{{RuleID: "STOP_001", Severity: "LOW"}}}
+	high := scanner.ScanResult{File: "high.json", Findings: []scanner.Finding{{RuleID: "TEMP_001", Severity: "HIGH"}}}
+	failed := scanner.ScanResult{File: "bad.json", Findings: []scanner.Finding{}, Error: "failed to parse"}
+
+	tests := []struct {
+		name    string
+		results []scanner.ScanResult
+		failOn  string
+		noFail  bool
+		want    int
+	}{
+		{name: "clean", results: []scanner.ScanResult{clean}, want: 0},
+		{name: "any finding without threshold", results: []scanner.ScanResult{low}, want: 1},
+		{name: "finding at threshold", results: []scanner.ScanResult{low, high}, failOn: "HIGH", want: 1},
+		{name: "findings below threshold", results: []scanner.ScanResult{clean, low}, failOn: "HIGH", want: 3},
+		{name: "error wins", results: []scanner.ScanResult{high, failed}, want: 2},
+		{name: "error wins over no fail", results: []scanner.ScanResult{failed}, noFail: true, want: 2},
+		{name: "no fail", results: []scanner.ScanResult{high}, noFail: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := defaultExitCodes.forScan(tt.results, tt.failOn, tt.noFail); got != tt.want {
+				t.Errorf("forScan() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	codes := defaultExitCodes
+	if err := parseExitCode("below_threshold=0", &codes); err != nil || codes.BelowThreshold != 0 {
+		t.Errorf("expected below_threshold=0 to apply, got %+v (%v)", codes, err)
+	}
+	for _, value := range []string{"findings", "findings=x", "warnings=4", "error=300"} {
+		if err := parseExitCode(value, &codes); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestOutputTextDiffContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "model: gpt-4\nmax_tokens: 100\nsampling:\n  temperature: 1.9\n  top_p: 0.9\napi_key: sk-abcdefghijklmnopqrstuvwxyz123\nuser: test\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	results := []scanner.ScanResult{{File: "config.yaml", AbsolutePath: path, Findings: []scanner.Finding{
+		{RuleID: "TEMP_001", Name: "High Temperature", Severity: "HIGH", Location: "temperature", Line: 4},
+		{RuleID: "NO_LINE", Name: "Missing Field", Severity: "LOW", Location: "rate_limit"},
+	}}}
+
+	var out bytes.Buffer
+	outputText(&out, results, outputOptions{plain: true, diffContext: 2})
+	text := out.String()
+
+	for _, want := range []string{
+		"Location: temperature (line 4)",
+		"     2 | max_tokens: 100",
+		"     3 | sampling:",
+		"   > 4 |   temperature: 1.9",
+		"     5 |   top_p: 0.9",
+		"     6 | api_key: " + scanner.MaskSecret("sk-abcdefghijklmnopqrstuvwxyz123"),
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in output:\n%s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"model: gpt-4", "user: test", "sk-abcdefghijklmnopqrstuvwxyz123"} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("expected %q to be outside the context, got:\n%s", unwanted, text)
+		}
+	}
+	if !strings.Contains(text, "Location: rate_limit\n") {
+		t.Errorf("expected a finding without a line to print no context, got:\n%s", text)
+	}
+}
+
+func TestLoadSeverityMap(t *testing.T) {
+	labels, err := loadSeverityMap("CRITICAL=P1, high=P2")
+	if err != nil {
+		t.Fatalf("inline map: %v", err)
+	}
+	if labels.label("CRITICAL") != "P1" || labels.label("HIGH") != "P2" || labels.label("LOW") != "LOW" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	for value, want := range map[string]string{"P1": "CRITICAL", "p2": "HIGH", "medium": "MEDIUM"} {
+		if got, ok := labels.resolve(value); !ok || got != want {
+			t.Errorf("resolve(%q) = %q, %v, want %q", value, got, ok, want)
+		}
+	}
+	if _, ok := labels.resolve("P3"); ok {
+		t.Error("expected an unmapped label not to resolve")
+	}
+
+	mapFile := filepath.Join(t.TempDir(), "severities.yaml")
+	if err := os.WriteFile(mapFile, []byte("CRITICAL: 1\nHIGH: 2\nMEDIUM: 3\nLOW: 4\n"), 0644); err != nil {
+		t.Fatalf("failed to write map: %v", err)
+	}
+	labels, err = loadSeverityMap(mapFile)
+	if err != nil {
+		t.Fatalf("map file: %v", err)
+	}
+	if labels.label("MEDIUM") != "3" || labels.choices() != "1, 2, 3, or 4" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+
+	for _, value := range []string{"SEVERE=P1", "CRITICAL=", "CRITICAL=P1,HIGH=p1", "LOW=CRITICAL", "CRITICAL", filepath.Join(t.TempDir(), "missing.yaml")} {
+		if _, err := loadSeverityMap(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTarGz := func(name string, entries map[string]string) string {
+		path := filepath.Join(tmpDir, name)
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, entryName := range sortedNames(entries) {
+			content := entries[entryName]
+			if err := tw.WriteHeader(&tar.Header{Name: entryName, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatalf("failed to write header: %v", err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatalf("failed to write entry: %v", err)
+			}
+		}
+		tw.Close()
+		gz.Close()
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("failed to write archive: %v", err)
+		}
+		return path
+	}
+
+	archive := writeTarGz("bundle.tar.gz", map[string]string{
+		"./prod/llm.yaml": "temperature: 1.5\n",
+		"notes.md":        "# notes",
+		"dev/.env":        "MODEL=gpt-4\n",
+	})
+	dir := t.TempDir()
+	names, err := extractArchive(archive, dir)
+	if err != nil {
+		t.Fatalf("extractArchive failed: %v", err)
+	}
+	if want := []string{"prod/llm.yaml", "dev/.env"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "prod", "llm.yaml")); err != nil || string(data) != "temperature: 1.5\n" {
+		t.Errorf("extracted content = %q, %v", data, err)
+	}
+
+	escape := writeTarGz("escape.tgz", map[string]string{"../../evil.json": "{}"})
+	if _, err := extractArchive(escape, t.TempDir()); err == nil || !strings.Contains(err.Error(), "unsafe entry path") {
+		t.Errorf("expected an unsafe path error, got %v", err)
+	}
+
+	many := make(map[string]string)
+	for i := 0; i <= maxArchiveEntries; i++ {
+		many[fmt.Sprintf("f%05d.txt", i)] = ""
+	}
+	if _, err := extractArchive(writeTarGz("many.tar.gz", many), t.TempDir()); err == nil || !strings.Contains(err.Error(), "entries") {
+		t.Errorf("expected an entry limit error, got %v", err)
+	}
+}
+
+func sortedNames(entries map[string]string) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,133 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cacheFormat is bumped whenever cached results would no longer match what
+// a fresh scan reports
+const cacheFormat = 9
+
+// Cache stores scan results on disk keyed by file content, so repeated
+// scans only re-scan files that changed. Every entry is tied to a hash of the
+// scanner's rules and options; if those change the whole cache is discarded.
+// Checks added with RegisterCheck are not part of the hash.
+type Cache struct {
+	path    string
+	key     string
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+type cacheFile struct {
+	Key     string                `json:"key"`
+	Entries map[string]cacheEntry `json:"entries"`
+}
+
+type cacheEntry struct {
+	Hash   string     `json:"hash"`
+	Result ScanResult `json:"result"`
+}
+
+// LoadCache opens the cache at path for use with s. A missing or unreadable
+// cache file, or one written for different rules, starts an empty cache.
+func LoadCache(path string, s *Scanner) (*Cache, error) {
+	key, err := s.cacheKey()
+	if err != nil {
+		return nil, err
+	}
+	cache := &Cache{path: path, key: key, entries: make(map[string]cacheEntry)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache, nil
+	}
+	var stored cacheFile
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Key != key {
+		cache.dirty = true
+		return cache, nil
+	}
+	if stored.Entries != nil {
+		cache.entries = stored.Entries
+	}
+	return cache, nil
+}
+
+// Scan returns the cached result for filePath when its content is unchanged
+// and otherwise scans it with s and records the result. hit reports whether
+// the cached result was used. Remote sources are always scanned.
+func (c *Cache) Scan(s *Scanner, filePath string) (result ScanResult, hit bool, err error) {
+	if IsRemoteSource(filePath) {
+		result, err = s.ScanFile(filePath)
+		return result, false, err
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return ScanResult{}, false, fmt.Errorf("failed to read config file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	entryKey := filePath
+	if abs, err := filepath.Abs(filePath); err == nil {
+		entryKey = abs
+	}
+
+	if entry, ok := c.entries[entryKey]; ok && entry.Hash == hash {
+		entry.Result.File = filePath
+		return entry.Result, true, nil
+	}
+
+	result, err = s.ScanFile(filePath)
+	if err != nil {
+		return result, false, err
+	}
+	c.entries[entryKey] = cacheEntry{Hash: hash, Result: result}
+	c.dirty = true
+	return result, false, nil
+}
+
+// Save writes the cache back to disk if anything changed
+func (c *Cache) Save() error {
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(cacheFile{Key: c.key, Entries: c.entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+	if err := os.WriteFile(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+// cacheKey hashes everything besides the file itself that affects a scan
+// result: the rules and the scanner options
+func (s *Scanner) cacheKey() (string, error) {
+	data, err := json.Marshal(struct {
+		Format       int
+		Rules        RulesFile
+		ParseOptions ParseOptions
+		Tags         []string
+		RecordPassed bool
+		RiskWeights  map[string]int
+		FailFast     bool
+		Severities   map[string]string
+		ParseErrors  bool
+		Lines        bool
+		Comments     bool
+	}{cacheFormat, s.rules, s.ParseOptions, s.Tags, s.RecordPassed, s.RiskWeights, s.FailFast, s.SeverityOverrides, s.ParseErrorsAsFindings, s.ResolveLines, s.ScanComments})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash rules: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
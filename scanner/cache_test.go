@@ -0,0 +1,84 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	cachePath := filepath.Join(tmpDir, ".paramguard-cache")
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.9}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	rules := RulesFile{Rules: []Rule{{
+		ID:       "TEMP_001",
+		Severity: "HIGH",
+		Check:    Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0},
+	}}}
+	s, err := newScanner(rules)
+	if err != nil {
+		t.Fatalf("newScanner: %v", err)
+	}
+
+	scan := func(s *Scanner) (ScanResult, bool) {
+		t.Helper()
+		cache, err := LoadCache(cachePath, s)
+		if err != nil {
+			t.Fatalf("LoadCache: %v", err)
+		}
+		result, hit, err := cache.Scan(s, configFile)
+		if err != nil {
+			t.Fatalf("Scan: %v", err)
+		}
+		if err := cache.Save(); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		return result, hit
+	}
+
+	first, hit := scan(s)
+	if hit {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if len(first.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %+v", first.Findings)
+	}
+
+	second, hit := scan(s)
+	if !hit {
+		t.Fatal("expected a hit for an unchanged file")
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("cached result differs:\n got %+v\nwant %+v", second, first)
+	}
+
+	// Editing the file invalidates its entry
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	edited, hit := scan(s)
+	if hit {
+		t.Error("expected a miss after editing the file")
+	}
+	if len(edited.Findings) != 0 {
+		t.Errorf("expected the edited file to be rescanned, got %+v", edited.Findings)
+	}
+	if _, hit := scan(s); !hit {
+		t.Error("expected the rescanned result to be cached")
+	}
+
+	// Changing the rules invalidates the whole cache
+	rules.Rules[0].Check.Max = 0.4
+	changed, err := newScanner(rules)
+	if err != nil {
+		t.Fatalf("newScanner: %v", err)
+	}
+	if result, hit := scan(changed); hit || len(result.Findings) != 1 {
+		t.Errorf("expected a fresh scan after the rules changed, got hit=%v findings=%+v", hit, result.Findings)
+	}
+}
@@ -0,0 +1,107 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateCompound checks that compound rules have an ID, at least one
+// trigger, no ID shared with a regular rule, and only require rules that can
+// report findings: regular rules and the scanner's own findings, such as
+// duplicate keys. A typo in requires would otherwise never fire.
+func validateCompound(rules RulesFile) error {
+	ids := make(map[string]bool)
+	for _, rule := range rules.Rules {
+		ids[rule.ID] = true
+	}
+	triggers := map[string]bool{
+		ParseErrorRuleID:      true,
+		DuplicateKeyRuleID:    true,
+		CommentedSecretRuleID: true,
+	}
+	for id := range ids {
+		triggers[id] = true
+	}
+	for _, compound := range rules.Compound {
+		if compound.ID == "" {
+			return fmt.Errorf("compound rule %q has no id", compound.Name)
+		}
+		if ids[compound.ID] {
+			return fmt.Errorf("duplicate rule ID %q in compound rules", compound.ID)
+		}
+		ids[compound.ID] = true
+		if len(compound.Requires) == 0 {
+			return fmt.Errorf("compound rule %s requires no rules", compound.ID)
+		}
+		for _, id := range compound.Requires {
+			if !triggers[id] {
+				return fmt.Errorf("compound rule %s requires unknown rule %q", compound.ID, id)
+			}
+		}
+		if compound.Severity != "" && !IsValidSeverity(compound.Severity) {
+			return fmt.Errorf("compound rule %s has invalid severity %q", compound.ID, compound.Severity)
+		}
+	}
+	return nil
+}
+
+// addCompound appends to a file's findings one for each compound rule whose
+// required rules all appear among them, with the profile's severity
+// overrides applied. It runs once per file, after every other finding
+// (embedded and decoded configs, commented secrets, parse errors) is in.
+func (s *Scanner) addCompound(findings []Finding, profile *Profile) []Finding {
+	for _, finding := range s.compoundFindings(findings) {
+		profile.apply(&finding)
+		s.overrideSeverity(&finding)
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// compoundFindings returns a finding for each compound rule whose required
+// rules all appear in findings
+func (s *Scanner) compoundFindings(findings []Finding) []Finding {
+	if len(s.rules.Compound) == 0 {
+		return nil
+	}
+
+	fired := make(map[string]bool)
+	for _, finding := range findings {
+		fired[finding.RuleID] = true
+	}
+
+	var compound []Finding
+	for _, rule := range s.rules.Compound {
+		matched := true
+		for _, id := range rule.Requires {
+			if !fired[id] {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		severity := strings.ToUpper(rule.Severity)
+		if severity == "" {
+			severity = "CRITICAL"
+		}
+		description := rule.Description
+		if description == "" {
+			description = "These findings occur together: " + strings.Join(rule.Requires, ", ")
+		}
+		compound = append(compound, Finding{
+			RuleID:         rule.ID,
+			Name:           rule.Name,
+			Severity:       severity,
+			Category:       rule.Category,
+			Description:    description,
+			Location:       strings.Join(rule.Requires, " + "),
+			Recommendation: rule.Recommendation,
+			References:     rule.References,
+			RuleSetVersion: rule.RuleSetVersion,
+		})
+	}
+	return compound
+}
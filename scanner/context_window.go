@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DefaultContextWindows maps model names to their context window in tokens.
+// A model matches the longest name it starts with followed by a separator
+// such as "-", so gpt-4o-2024-08-06 uses the gpt-4o entry but gpt-4.5 does
+// not use gpt-4. Releases whose window differs from their family's, like the
+// gpt-4 previews, are listed by name. Rules files can add or override
+// entries with context_windows.
+var DefaultContextWindows = map[string]int{
+	"gpt-3.5-turbo":      16385,
+	"gpt-4":              8192,
+	"gpt-4-32k":          32768,
+	"gpt-4-turbo":        128000,
+	"gpt-4-1106-preview": 128000,
+	"gpt-4-0125-preview": 128000,
+	"gpt-4-vision":       128000,
+	"gpt-4.5":            128000,
+	"gpt-4o":             128000,
+	"gpt-4o-mini":        128000,
+	"gpt-4.1":            1047576,
+	"o1":                 200000,
+	"o1-mini":            128000,
+	"o3":                 200000,
+	"o3-mini":            200000,
+	"o4-mini":            200000,
+	"claude-2":           100000,
+	"claude-3":           200000,
+	"claude-3-5":         200000,
+	"claude-3-7":         200000,
+	"claude-opus-4":      200000,
+	"claude-sonnet-4":    200000,
+	"gemini-1.5-flash":   1048576,
+	"gemini-1.5-pro":     2097152,
+	"gemini-2.0-flash":   1048576,
+	"gemini-2.5":         1048576,
+	"llama-3":            8192,
+	"llama-3.1":          131072,
+	"llama-3.2":          131072,
+	"llama-3.3":          131072,
+	"mistral-large":      131072,
+	"mistral-small":      32768,
+	"command-r":          128000,
+	"command-r-plus":     128000,
+	"deepseek-chat":      65536,
+	"deepseek-reasoner":  65536,
+}
+
+// contextWindow returns the context window for model from windows, falling
+// back to DefaultContextWindows, by longest matching name
+func contextWindow(model string, windows map[string]int) (int, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	best, bestLen := 0, -1
+	for _, table := range []map[string]int{windows, DefaultContextWindows} {
+		for name, window := range table {
+			name = strings.ToLower(name)
+			if len(name) > bestLen && modelMatches(model, name) {
+				best, bestLen = window, len(name)
+			}
+		}
+		if bestLen >= 0 {
+			return best, true
+		}
+	}
+	return 0, false
+}
+
+// modelMatches reports whether model is name or a release of it: name
+// followed by anything but a letter, digit, or ".", so gpt-4-0613 and
+// gpt-4o-mini-2024-07-18 match gpt-4 and gpt-4o-mini, but gpt-4o and
+// gpt-4.5 don't match gpt-4
+func modelMatches(model, name string) bool {
+	if !strings.HasPrefix(model, name) {
+		return false
+	}
+	if len(model) == len(name) {
+		return true
+	}
+	next := rune(model[len(name)])
+	return next != '.' && !unicode.IsLetter(next) && !unicode.IsDigit(next)
+}
+
+// checkContextWindowExceeded flags a max_tokens value larger than the
+// context window of the config's model. Unknown models are compared against
+// default_context_window, or skipped when it is unset.
+func checkContextWindowExceeded(rule Rule, config *Config) checkResult {
+	var model string
+	for _, found := range config.findFieldValues("model") {
+		if name, ok := found.value.(string); ok {
+			model = name
+			break
+		}
+	}
+	if model == "" {
+		return checkResult{}
+	}
+
+	window, known := contextWindow(model, rule.Check.ContextWindows)
+	if !known {
+		window = rule.Check.DefaultContextWindow
+	}
+	if window <= 0 {
+		return checkResult{}
+	}
+
+	for _, found := range config.findFieldValues("max_tokens") {
+		tokens, ok := toFloat(found.value)
+		if !ok || tokens <= float64(window) {
+			continue
+		}
+		detail := fmt.Sprintf("max_tokens = %v exceeds the %d-token context window of %s", found.value, window, model)
+		if !known {
+			detail = fmt.Sprintf("max_tokens = %v exceeds the default %d-token context window for unknown model %s", found.value, window, model)
+		}
+		return checkResult{violated: true, location: "max_tokens", path: found.path, detail: detail}
+	}
+	return checkResult{}
+}
+
+// applyContextWindows copies a rules file's context_windows table into each
+// of its context_window_exceeded checks, keeping entries the check sets
+// itself
+func applyContextWindows(rules *RulesFile) {
+	if len(rules.ContextWindows) == 0 {
+		return
+	}
+	for _, list := range [][]Rule{rules.Rules, rules.Templates} {
+		for i := range list {
+			checks := []*Check{&list[i].Check}
+			for j := range list[i].Checks {
+				checks = append(checks, &list[i].Checks[j])
+			}
+			for _, check := range checks {
+				if check.Type != "context_window_exceeded" {
+					continue
+				}
+				merged := make(map[string]int, len(rules.ContextWindows)+len(check.ContextWindows))
+				for model, window := range rules.ContextWindows {
+					merged[model] = window
+				}
+				for model, window := range check.ContextWindows {
+					merged[model] = window
+				}
+				check.ContextWindows = merged
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DiffEntry is a finding together with the file it was reported for
+type DiffEntry struct {
+	File    string  `json:"file"`
+	Finding Finding `json:"finding"`
+}
+
+// Diff holds the result of comparing two scans
+type Diff struct {
+	Added     []DiffEntry `json:"added"`
+	Removed   []DiffEntry `json:"removed"`
+	Unchanged []DiffEntry `json:"unchanged"`
+}
+
+// DiffResults compares a base scan with a head scan. Findings are matched on
+// their FingerprintID, or on Fingerprint when a report has none; a finding
+// reported twice in head but once in base counts as one unchanged and one
+// added.
+func DiffResults(base, head []ScanResult) Diff {
+	diff := Diff{
+		Added:     []DiffEntry{},
+		Removed:   []DiffEntry{},
+		Unchanged: []DiffEntry{},
+	}
+
+	remaining := make(map[string]int)
+	for _, result := range base {
+		for _, finding := range result.Findings {
+			remaining[fingerprintOf(result.File, finding)]++
+		}
+	}
+
+	for _, result := range head {
+		for _, finding := range result.Findings {
+			key := fingerprintOf(result.File, finding)
+			entry := DiffEntry{File: result.File, Finding: finding}
+			if remaining[key] > 0 {
+				remaining[key]--
+				diff.Unchanged = append(diff.Unchanged, entry)
+			} else {
+				diff.Added = append(diff.Added, entry)
+			}
+		}
+	}
+
+	for _, result := range base {
+		for _, finding := range result.Findings {
+			key := fingerprintOf(result.File, finding)
+			if remaining[key] > 0 {
+				remaining[key]--
+				diff.Removed = append(diff.Removed, DiffEntry{File: result.File, Finding: finding})
+			}
+		}
+	}
+
+	return diff
+}
+
+// Fingerprint returns a short, stable identity for the finding reported in
+// file. It hashes the rule ID, file, path (or location when there is no
+// path), and evidence, so the same finding gets the same fingerprint on every
+// run regardless of the order findings are reported in. Redacting evidence
+// changes the result, which is why ScanFile records it in FingerprintID
+// before reports redact anything.
+func (f Finding) Fingerprint(file string) string {
+	where := f.Path
+	if where == "" {
+		where = f.Location
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{f.RuleID, file, where, f.Evidence}, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// fingerprintOf returns the finding's recorded FingerprintID, computing it
+// for findings from reports that predate it
+func fingerprintOf(file string, f Finding) string {
+	if f.FingerprintID != "" {
+		return f.FingerprintID
+	}
+	return f.Fingerprint(file)
+}
+
+// setFingerprints records each finding's Fingerprint in file
+func setFingerprints(file string, findings []Finding) {
+	for i := range findings {
+		findings[i].FingerprintID = findings[i].Fingerprint(file)
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiffResults(t *testing.T) {
+	base := []ScanResult{
+		{
+			File: "config.json",
+			Findings: []Finding{
+				{RuleID: "TEMP_001", Location: "temperature"},
+				{RuleID: "SEED_001", Location: "seed"},
+			},
+		},
+	}
+	head := []ScanResult{
+		{
+			File: "config.json",
+			Findings: []Finding{
+				{RuleID: "TEMP_001", Location: "temperature"},
+				{RuleID: "SECRETS_001", Location: "api_key"},
+			},
+		},
+		{
+			File: "other.json",
+			Findings: []Finding{
+				{RuleID: "TEMP_001", Location: "temperature"},
+			},
+		},
+	}
+
+	diff := DiffResults(base, head)
+
+	if len(diff.Unchanged) != 1 || diff.Unchanged[0].Finding.RuleID != "TEMP_001" || diff.Unchanged[0].File != "config.json" {
+		t.Errorf("unexpected unchanged findings: %+v", diff.Unchanged)
+	}
+
+	// The same rule in a different file is a new finding
+	if len(diff.Added) != 2 {
+		t.Fatalf("expected 2 added findings, got %+v", diff.Added)
+	}
+	if diff.Added[0].Finding.RuleID != "SECRETS_001" || diff.Added[1].File != "other.json" {
+		t.Errorf("unexpected added findings: %+v", diff.Added)
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0].Finding.RuleID != "SEED_001" {
+		t.Errorf("unexpected removed findings: %+v", diff.Removed)
+	}
+}
+
+func TestDiffResults_LocationMatters(t *testing.T) {
+	base := []ScanResult{{File: "a.json", Findings: []Finding{{RuleID: "TEMP_001", Location: "temperature"}}}}
+	head := []ScanResult{{File: "a.json", Findings: []Finding{
+		{RuleID: "TEMP_001", Location: "temperature"},
+		{RuleID: "TEMP_001", Location: "settings.temperature"},
+	}}}
+
+	diff := DiffResults(base, head)
+	if len(diff.Added) != 1 || diff.Added[0].Finding.Location != "settings.temperature" {
+		t.Errorf("expected the new location to be added, got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 0 || len(diff.Unchanged) != 1 {
+		t.Errorf("unexpected diff: %+v", diff)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	finding := Finding{RuleID: "SECRETS_001", Name: "Hardcoded key", Location: "api_key", Path: "$.api_key", Evidence: "sk-p…901"}
+
+	fingerprint := finding.Fingerprint("config.json")
+	if len(fingerprint) != 16 {
+		t.Errorf("expected a 16 character fingerprint, got %q", fingerprint)
+	}
+
+	// Fields outside the identity don't change the fingerprint
+	same := finding
+	same.Name = "Renamed"
+	same.Severity = "LOW"
+	same.Line = 12
+	if got := same.Fingerprint("config.json"); got != fingerprint {
+		t.Errorf("expected identical fingerprints, got %q and %q", fingerprint, got)
+	}
+
+	tests := []struct {
+		name    string
+		finding Finding
+		file    string
+	}{
+		{"different file", finding, "other.json"},
+		{"different rule", Finding{RuleID: "SECRETS_002", Location: "api_key", Path: "$.api_key", Evidence: "sk-p…901"}, "config.json"},
+		{"different path", Finding{RuleID: "SECRETS_001", Location: "api_key", Path: "$.auth.api_key", Evidence: "sk-p…901"}, "config.json"},
+		{"location without a path", Finding{RuleID: "SECRETS_001", Location: "auth.api_key", Evidence: "sk-p…901"}, "config.json"},
+		{"different evidence", Finding{RuleID: "SECRETS_001", Location: "api_key", Path: "$.api_key", Evidence: "sk-a…123"}, "config.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.finding.Fingerprint(tt.file); got == fingerprint {
+				t.Errorf("expected a different fingerprint, got %q", got)
+			}
+		})
+	}
+}
+
+func TestScanner_ScanFileSetsFingerprints(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "TEMP_001", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("ScanFile: %v", err)
+	}
+	if len(result.Findings) != 1 {
+		t.Fatalf("expected one finding, got %+v", result.Findings)
+	}
+	finding := result.Findings[0]
+	if finding.FingerprintID == "" || finding.FingerprintID != finding.Fingerprint(configFile) {
+		t.Errorf("FingerprintID = %q, want %q", finding.FingerprintID, finding.Fingerprint(configFile))
+	}
+}
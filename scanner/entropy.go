@@ -0,0 +1,128 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Defaults for entropy checks that leave min_entropy or min_length unset
+const (
+	defaultMinEntropy       = 4.0
+	defaultEntropyMinLength = 20
+)
+
+// checkEntropy flags random-looking tokens in string values: any
+// whitespace-separated token of at least min_length characters whose Shannon
+// entropy is at least min_entropy bits per character. It inspects check.path,
+// then check.fields or the rule's fields, and otherwise every string in the
+// config. Every flagged value is reported.
+func checkEntropy(rule Rule, config *Config) []checkResult {
+	check := rule.Check
+	minEntropy := check.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = defaultMinEntropy
+	}
+	minLength := check.MinLength
+	if minLength == 0 {
+		minLength = defaultEntropyMinLength
+	}
+
+	var leaves []stringLeaf
+	switch {
+	case check.Path != "":
+		for _, found := range pathValues(check.Path, config) {
+			leaves = append(leaves, stringLeaf{location: check.Path, fieldValue: found})
+		}
+	case len(check.fieldNames()) > 0 || len(rule.Fields) > 0:
+		fields := check.fieldNames()
+		if len(fields) == 0 {
+			fields = rule.Fields
+		}
+		for _, field := range fields {
+			for _, found := range config.findFieldValues(field) {
+				leaves = append(leaves, stringLeaf{location: field, fieldValue: found})
+			}
+		}
+	default:
+		collectStrings(config.Data, "", "$", &leaves)
+	}
+
+	var results []checkResult
+	for _, leaf := range leaves {
+		if result := entropyResult(leaf, minEntropy, minLength, check); result.violated {
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// stringLeaf is a candidate value for an entropy check along with the
+// location reported if it is flagged
+type stringLeaf struct {
+	location string
+	fieldValue
+}
+
+func entropyResult(leaf stringLeaf, minEntropy float64, minLength int, check Check) checkResult {
+	str, ok := leaf.value.(string)
+	if !ok {
+		return checkResult{}
+	}
+	for _, token := range strings.FieldsFunc(str, unicode.IsSpace) {
+		if len(token) < minLength {
+			continue
+		}
+		if entropy := shannonEntropy(token); entropy >= minEntropy {
+			detail := fmt.Sprintf("%d-character token with entropy %.2f bits/char (min %s)", len(token), entropy, formatNumber(minEntropy))
+			return checkResult{violated: true, location: leaf.location, path: leaf.path, evidence: evidenceFor(check, token), detail: detail}
+		}
+	}
+	return checkResult{}
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// collectStrings gathers every string leaf under val, descending into maps
+// and arrays. Locations use the same dotted form as key_pattern.
+func collectStrings(val interface{}, location, path string, leaves *[]stringLeaf) {
+	switch v := val.(type) {
+	case string:
+		*leaves = append(*leaves, stringLeaf{location: location, fieldValue: fieldValue{path: path, value: v}})
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			collectStrings(v[key], joinLocation(location, key), jsonPathKey(path, key), leaves)
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectStrings(item, joinLocation(location, strconv.Itoa(i)), jsonPathIndex(path, i), leaves)
+		}
+	}
+}
+
+func joinLocation(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveExtends fills unset fields of every rule that names a base with
+// extends. Bases are looked up among templates first, then rules, and may
+// themselves extend another base. Cycles and unknown bases are errors.
+func resolveExtends(rules *RulesFile) error {
+	bases := make(map[string]*Rule)
+	for i := range rules.Rules {
+		bases[rules.Rules[i].ID] = &rules.Rules[i]
+	}
+	for i := range rules.Templates {
+		bases[rules.Templates[i].ID] = &rules.Templates[i]
+	}
+
+	resolved := make(map[string]bool)
+	var resolve func(rule *Rule, chain []string) error
+	resolve = func(rule *Rule, chain []string) error {
+		if rule.Extends == "" || resolved[rule.ID] {
+			return nil
+		}
+		for _, id := range chain {
+			if id == rule.ID {
+				return fmt.Errorf("rule inheritance cycle: %s -> %s", strings.Join(chain, " -> "), rule.ID)
+			}
+		}
+
+		base, ok := bases[rule.Extends]
+		if !ok {
+			return fmt.Errorf("rule %s extends unknown rule or template %q", rule.ID, rule.Extends)
+		}
+		if err := resolve(base, append(chain, rule.ID)); err != nil {
+			return err
+		}
+
+		inherit(rule, base)
+		resolved[rule.ID] = true
+		return nil
+	}
+
+	for i := range rules.Templates {
+		if err := resolve(&rules.Templates[i], nil); err != nil {
+			return err
+		}
+	}
+	for i := range rules.Rules {
+		if err := resolve(&rules.Rules[i], nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inherit copies fields the child leaves unset from base. The check (or
+// checks) is inherited only as a whole, when the child has none.
+func inherit(child *Rule, base *Rule) {
+	if child.Name == "" {
+		child.Name = base.Name
+	}
+	if child.Severity == "" {
+		child.Severity = base.Severity
+	}
+	if child.Category == "" {
+		child.Category = base.Category
+	}
+	if child.Description == "" {
+		child.Description = base.Description
+	}
+	if child.Check.Type == "" && len(child.Checks) == 0 {
+		child.Check = base.Check
+		child.Checks = base.Checks
+		child.Logic = base.Logic
+	}
+	if child.Recommendation == "" {
+		child.Recommendation = base.Recommendation
+	}
+	if child.RecommendationTemplate == "" {
+		child.RecommendationTemplate = base.RecommendationTemplate
+	}
+	if len(child.AppliesWhen) == 0 {
+		child.AppliesWhen = base.AppliesWhen
+	}
+	if len(child.References) == 0 {
+		child.References = base.References
+	}
+	if len(child.Fields) == 0 {
+		child.Fields = base.Fields
+	}
+	if len(child.Tags) == 0 {
+		child.Tags = base.Tags
+	}
+	if child.CWE == "" {
+		child.CWE = base.CWE
+	}
+	if child.MaxFindings == 0 {
+		child.MaxFindings = base.MaxFindings
+	}
+}
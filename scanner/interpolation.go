@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// interpolatedValue matches values that are entirely a ${...} or $VAR
+// reference to the environment
+var interpolatedValue = regexp.MustCompile(`^(\$\{[^{}]+\}|\$[A-Za-z_][A-Za-z0-9_]*)$`)
+
+// checkRequiresInterpolation flags sensitive fields whose values are
+// literals rather than ${VAR} or $VAR references, catching secrets that no
+// known-prefix pattern matches. It inspects check.path, then check.fields or
+// the rule's fields; each string in an array is checked on its own. Every
+// literal value is reported.
+func checkRequiresInterpolation(rule Rule, config *Config) []checkResult {
+	check := rule.Check
+	var leaves []stringLeaf
+	switch {
+	case check.Path != "":
+		for _, found := range pathValues(check.Path, config) {
+			leaves = append(leaves, stringLeaf{location: check.Path, fieldValue: found})
+		}
+	default:
+		fields := check.fieldNames()
+		if len(fields) == 0 {
+			fields = rule.Fields
+		}
+		for _, field := range fields {
+			for _, found := range config.findFieldValues(field) {
+				leaves = append(leaves, stringLeaf{location: field, fieldValue: found})
+			}
+		}
+	}
+
+	var results []checkResult
+	for _, leaf := range leaves {
+		items, isArray := leaf.value.([]interface{})
+		if !isArray {
+			if result, ok := literalResult(leaf, check); ok {
+				results = append(results, result)
+			}
+			continue
+		}
+		for i, item := range items {
+			element := stringLeaf{
+				location:   fmt.Sprintf("%s.%d", leaf.location, i),
+				fieldValue: fieldValue{path: jsonPathIndex(leaf.path, i), value: item},
+			}
+			if result, ok := literalResult(element, check); ok {
+				results = append(results, result)
+			}
+		}
+	}
+	return results
+}
+
+// literalResult reports a string or number value that is not an
+// environment reference. Empty strings hold no secret and pass.
+func literalResult(leaf stringLeaf, check Check) (checkResult, bool) {
+	var value string
+	switch v := leaf.value.(type) {
+	case string:
+		value = strings.TrimSpace(v)
+	case float64, int, int64:
+		value = fmt.Sprintf("%v", v)
+	default:
+		return checkResult{}, false
+	}
+	if value == "" || interpolatedValue.MatchString(value) {
+		return checkResult{}, false
+	}
+
+	detail := fmt.Sprintf("%s holds a literal value instead of a ${VAR} or $VAR reference", leaf.location)
+	if strings.Contains(value, "$") {
+		detail = fmt.Sprintf("%s is only partly interpolated", leaf.location)
+	}
+	return checkResult{violated: true, location: leaf.location, path: leaf.path, evidence: evidenceFor(check, value), detail: detail}, true
+}
@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolveLines sets Line on the findings whose position in a local file
+// can be found. In JSON and YAML files a finding's Pointer is followed
+// through the document to the line of the deepest key or element it
+// reaches; in .env, .properties, .ini, and .toml files the line assigning
+// the key it points to is used. Remote and compressed sources are skipped,
+// as are findings without a pointer.
+func resolveLines(filePath string, findings []Finding) {
+	if IsRemoteSource(filePath) || len(findings) == 0 {
+		return
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil || isGzip(data) {
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".cfg" || ext == ".conf" {
+		ext = sniffFormat(data)
+	}
+
+	switch ext {
+	case ".json", ".jsonc", ".json5", ".yaml", ".yml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return
+		}
+		for i := range findings {
+			findings[i].Line = nodeLine(&root, pointerTokens(findings[i].Pointer))
+		}
+	case ".env", ".properties", ".ini", ".toml":
+		lines := strings.Split(string(data), "\n")
+		for i := range findings {
+			tokens := pointerTokens(findings[i].Pointer)
+			if len(tokens) > 0 {
+				findings[i].Line = assignmentLine(lines, tokens[len(tokens)-1])
+			}
+		}
+	}
+}
+
+// pointerTokens splits a JSON Pointer into its unescaped reference tokens
+func pointerTokens(pointer string) []string {
+	if pointer == "" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// nodeLine follows tokens from the document root and returns the line of
+// the last key or element reached, or 0 if the first token is not found.
+// A path that continues into a config embedded in a string stops at that
+// string.
+func nodeLine(node *yaml.Node, tokens []string) int {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line := 0
+	for _, token := range tokens {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+		switch node.Kind {
+		case yaml.MappingNode:
+			found := false
+			for j := 0; j+1 < len(node.Content); j += 2 {
+				if node.Content[j].Value == token {
+					line = node.Content[j].Line
+					node = node.Content[j+1]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return line
+			}
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return line
+			}
+			node = node.Content[index]
+			line = node.Line
+		default:
+			return line
+		}
+	}
+	return line
+}
+
+// assignmentLine returns the 1-based number of the first line assigning
+// key with = or :, or 0 when there is none
+func assignmentLine(lines []string, key string) int {
+	assignment := regexp.MustCompile(`^\s*(?:export\s+)?` + regexp.QuoteMeta(key) + `\s*[=:]`)
+	for i, line := range lines {
+		if assignment.MatchString(line) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"container/list"
+	"path/filepath"
+	"sync"
+)
+
+// maxParsedFiles and maxParsedBytes bound the parse cache, so a long
+// recursive scan, --watch, or a library caller doesn't keep every config it
+// has ever parsed
+const (
+	maxParsedFiles = 256
+	maxParsedBytes = 32 << 20
+)
+
+// parsedFile is a local config parsed recently, with the content hash and
+// options it was parsed under
+type parsedFile struct {
+	key    string
+	sum    [32]byte
+	size   int
+	opts   ParseOptions
+	config *Config
+}
+
+// parseCache lets ParseConfigFileWithOptions parse a local file once when it
+// is scanned repeatedly, such as by several scanners. Entries are keyed on the
+// absolute path and only reused when the file's content hash and the parse
+// options match, so a file rewritten within the filesystem's timestamp
+// granularity is still parsed again. The least recently used entries are
+// dropped beyond maxParsedFiles files or maxParsedBytes of source. Stored
+// configs are private copies and every caller gets its own copy, so no
+// caller can change what another sees.
+var parseCache = struct {
+	sync.Mutex
+	files map[string]*list.Element
+	order *list.List
+	bytes int
+}{files: make(map[string]*list.Element), order: list.New()}
+
+// cachedConfig returns a copy of the config parsed from filePath when its
+// content hashed to sum, if there is one
+func cachedConfig(filePath string, sum [32]byte, opts ParseOptions) (*Config, bool) {
+	key, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, false
+	}
+
+	parseCache.Lock()
+	elem, ok := parseCache.files[key]
+	if ok {
+		parseCache.order.MoveToFront(elem)
+	}
+	parseCache.Unlock()
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*parsedFile)
+	if entry.sum != sum || entry.opts != opts {
+		return nil, false
+	}
+
+	config := copyConfig(entry.config)
+	config.FilePath = filePath
+	return config, true
+}
+
+// storeConfig remembers a copy of config as parsed from size bytes of
+// filePath content hashing to sum
+func storeConfig(filePath string, sum [32]byte, size int, opts ParseOptions, config *Config) {
+	key, err := filepath.Abs(filePath)
+	if err != nil || size > maxParsedBytes {
+		return
+	}
+
+	entry := &parsedFile{key: key, sum: sum, size: size, opts: opts, config: copyConfig(config)}
+	parseCache.Lock()
+	defer parseCache.Unlock()
+	if elem, ok := parseCache.files[key]; ok {
+		removeParsed(elem)
+	}
+	parseCache.files[key] = parseCache.order.PushFront(entry)
+	parseCache.bytes += size
+	for parseCache.order.Len() > maxParsedFiles || parseCache.bytes > maxParsedBytes {
+		removeParsed(parseCache.order.Back())
+	}
+}
+
+// removeParsed drops an entry from the parse cache; the lock must be held
+func removeParsed(elem *list.Element) {
+	entry := parseCache.order.Remove(elem).(*parsedFile)
+	delete(parseCache.files, entry.key)
+	parseCache.bytes -= entry.size
+}
+
+// copyConfig deep-copies config's data so the copy can be modified freely
+func copyConfig(config *Config) *Config {
+	copied := *config
+	copied.Data = copyValue(config.Data).(map[string]interface{})
+	if config.DuplicateKeys != nil {
+		copied.DuplicateKeys = append([]string(nil), config.DuplicateKeys...)
+	}
+	return &copied
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			copied[key] = copyValue(val)
+		}
+		return copied
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]interface{}, len(v))
+		for i, val := range v {
+			copied[i] = copyValue(val)
+		}
+		return copied
+	case []map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		copied := make([]map[string]interface{}, len(v))
+		for i, val := range v {
+			copied[i] = copyValue(val).(map[string]interface{})
+		}
+		return copied
+	}
+	return value
+}
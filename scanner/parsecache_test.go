@@ -0,0 +1,92 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseConfigFile_ParsesOncePerRun(t *testing.T) {
+	parses := 0
+	parseFile = func(filePath string, data []byte, ext string, opts ParseOptions) (*Config, error) {
+		parses++
+		return parseContent(filePath, data, ext, opts)
+	}
+	defer func() { parseFile = parseContent }()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("sampling:\n  temperature: 0.7\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	first, err := ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Changing one caller's config must not leak into later parses
+	first.Data["sampling"].(map[string]interface{})["temperature"] = 2.0
+
+	for i := 0; i < 3; i++ {
+		config, err := ParseConfigFile(path)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got, _ := config.GetValue("sampling.temperature"); got != 0.7 {
+			t.Fatalf("sampling.temperature = %v, want 0.7 from an unmodified copy", got)
+		}
+	}
+	if parses != 1 {
+		t.Errorf("expected the file to be parsed once, got %d parses", parses)
+	}
+
+	// Different options parse again
+	if _, err := ParseConfigFileWithOptions(path, ParseOptions{NormalizeValues: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if parses != 2 {
+		t.Errorf("expected new options to parse the file again, got %d parses", parses)
+	}
+
+	// So does changed content, even with the same size and modification time
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("failed to stat test file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("sampling:\n  temperature: 0.9\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatalf("failed to restore modification time: %v", err)
+	}
+	config, err := ParseConfigFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := config.GetValue("sampling.temperature"); got != 0.9 {
+		t.Errorf("sampling.temperature = %v, want 0.9 after the file changed", got)
+	}
+	if parses != 3 {
+		t.Errorf("expected the changed file to be parsed again, got %d parses", parses)
+	}
+}
+
+func TestParseConfigFile_CacheIsBounded(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < maxParsedFiles+10; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("config%d.json", i))
+		if err := os.WriteFile(path, []byte(fmt.Sprintf(`{"seed": %d}`, i)), 0644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		if _, err := ParseConfigFile(path); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	parseCache.Lock()
+	files, entries := len(parseCache.files), parseCache.order.Len()
+	parseCache.Unlock()
+	if files > maxParsedFiles || entries != files {
+		t.Errorf("cache holds %d files (%d entries), want at most %d", files, entries, maxParsedFiles)
+	}
+}
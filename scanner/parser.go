@@ -2,52 +2,210 @@ package scanner
 
 import (
 	"bufio"
+	"bytes"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"os"
+	"io"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultMaxFileSize is the largest config file read when
+// ParseOptions.MaxFileSize is zero
+const DefaultMaxFileSize int64 = 10 << 20
+
+// ParseOptions controls optional parser behavior
+type ParseOptions struct {
+	// ExpandEnvKeys turns dotted (rate_limit.rpm) and double-underscore
+	// (RATE_LIMIT__RPM) keys in .env files into nested maps
+	ExpandEnvKeys bool
+
+	// StrictJSON decodes .json files token by token and records duplicate
+	// keys in Config.DuplicateKeys instead of silently keeping the last value
+	StrictJSON bool
+
+	// MaxFileSize rejects files larger than this many bytes before reading
+	// them. Zero uses DefaultMaxFileSize; a negative value disables the limit.
+	MaxFileSize int64
+
+	// TolerantJSON accepts comments and trailing commas in .json files, as
+	// is always done for .jsonc and .json5
+	TolerantJSON bool
+
+	// NormalizeValues converts "true"/"false" and numeric strings into
+	// booleans and numbers after parsing (see NormalizeConfig)
+	NormalizeValues bool
+
+	// FetchTimeout bounds fetching a config from a URL. Zero uses
+	// DefaultFetchTimeout.
+	FetchTimeout time.Duration
+
+	// Select narrows ScanFile to the map at this dotted path (see
+	// Config.Select), so rules ignore the rest of the file
+	Select string
+
+	// UnwrapKey names a top-level key whose string values hold embedded
+	// configs. ScanFile scans those configs instead of the outer document.
+	// Kubernetes ConfigMap and Secret manifests are unwrapped without it.
+	UnwrapKey string
+
+	// DecodeBase64Values makes ScanFile also scan configs hidden in
+	// base64-encoded string values, at any depth
+	DecodeBase64Values bool
+}
+
 // ParseConfigFile parses a config file based on its extension
 func ParseConfigFile(filePath string) (*Config, error) {
-	ext := strings.ToLower(filepath.Ext(filePath))
+	return ParseConfigFileWithOptions(filePath, ParseOptions{})
+}
+
+// ParseConfigFileWithOptions parses a config file using the given options.
+// filePath may also be an http:// or https:// URL, and gzip-compressed
+// content (such as config.json.gz) is decompressed before parsing. A local
+// file whose content is unchanged since a recent parse is not parsed again;
+// callers get copies of the earlier result (see parseCache).
+func ParseConfigFileWithOptions(filePath string, opts ParseOptions) (*Config, error) {
+	data, ext, err := readSource(filePath, opts)
+	if err != nil {
+		return nil, err
+	}
+	if IsRemoteSource(filePath) {
+		return parseFile(filePath, data, ext, opts)
+	}
+
+	sum := sha256.Sum256(data)
+	if config, ok := cachedConfig(filePath, sum, opts); ok {
+		return config, nil
+	}
+	config, err := parseFile(filePath, data, ext, opts)
+	if err != nil {
+		return nil, err
+	}
+	storeConfig(filePath, sum, len(data), opts, config)
+	return config, nil
+}
+
+// parseFile parses a config's content; tests replace it to count parses
+var parseFile = parseContent
 
-	data, err := os.ReadFile(filePath)
+func parseContent(filePath string, data []byte, ext string, opts ParseOptions) (*Config, error) {
+	configData, duplicateKeys, err := parseData(data, ext, opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %w", err)
+		return nil, err
+	}
+
+	config := &Config{
+		Data:          configData,
+		FilePath:      filePath,
+		DuplicateKeys: duplicateKeys,
+	}
+	if opts.NormalizeValues {
+		NormalizeConfig(config)
 	}
+	return config, nil
+}
 
+// parseData parses config content in the format given by ext, returning the
+// duplicate keys found in strict JSON mode
+func parseData(data []byte, ext string, opts ParseOptions) (map[string]interface{}, []string, error) {
 	var configData map[string]interface{}
+	var duplicateKeys []string
+	var err error
+
+	if ext == ".jsonc" || ext == ".json5" || (ext == ".json" && opts.TolerantJSON) {
+		data = stripJSONComments(data)
+		ext = ".json"
+	}
+	if ext == ".cfg" || ext == ".conf" {
+		ext = sniffFormat(data)
+	}
 
 	switch ext {
 	case ".json":
-		configData, err = parseJSON(data)
+		if opts.StrictJSON {
+			configData, duplicateKeys, err = parseJSONStrict(data)
+		} else {
+			configData, err = parseJSON(data)
+		}
 	case ".yaml", ".yml":
 		configData, err = parseYAML(data)
 	case ".toml":
 		configData, err = parseTOML(data)
+	case ".hcl", ".tf":
+		configData, err = parseHCL(data)
 	case ".env":
 		configData, err = parseEnv(data)
+		if err == nil && opts.ExpandEnvKeys {
+			configData = expandEnvKeys(configData)
+		}
+	case ".properties":
+		configData, err = parseKeyValue(data, propertiesSyntax)
+	case ".ini":
+		configData, err = parseKeyValue(data, iniSyntax)
 	default:
 		// Try to detect format
 		configData, err = autoDetectFormat(data)
 		if err != nil {
-			return nil, fmt.Errorf("unsupported file format: %s", ext)
+			return nil, nil, fmt.Errorf("unsupported file format: %s", ext)
 		}
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return configData, duplicateKeys, nil
+}
 
-	return &Config{
-		Data:     configData,
-		FilePath: filePath,
-	}, nil
+var numericString = regexp.MustCompile(`^[-+]?(\d+\.?\d*|\.\d+)([eE][-+]?\d+)?$`)
+
+// NormalizeConfig coerces string values so that formats without types (.env,
+// INI, properties) behave like YAML and JSON: "true" and "false" (any case)
+// become booleans and decimal number strings become float64. It walks nested
+// maps and arrays and modifies config.Data in place.
+func NormalizeConfig(config *Config) {
+	for key, val := range config.Data {
+		config.Data[key] = normalizeValue(val)
+	}
+}
+
+func normalizeValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case string:
+		trimmed := strings.TrimSpace(v)
+		switch strings.ToLower(trimmed) {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		if numericString.MatchString(trimmed) {
+			if num, err := strconv.ParseFloat(trimmed, 64); err == nil {
+				return num
+			}
+		}
+		return v
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeValue(item)
+		}
+		return v
+	default:
+		return val
+	}
 }
 
 func parseJSON(data []byte) (map[string]interface{}, error) {
@@ -58,6 +216,152 @@ func parseJSON(data []byte) (map[string]interface{}, error) {
 	return result, nil
 }
 
+// stripJSONComments removes // and /* */ comments and trailing commas before
+// } or ] so the result can be decoded as standard JSON. String contents are
+// left untouched.
+func stripJSONComments(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				i = len(data)
+			} else {
+				i += end + 3
+			}
+			out = append(out, ' ')
+		case c == '}' || c == ']':
+			// Drop a trailing comma, ignoring whitespace after it
+			j := len(out) - 1
+			for j >= 0 && isJSONSpace(out[j]) {
+				j--
+			}
+			if j >= 0 && out[j] == ',' {
+				out = append(out[:j], out[j+1:]...)
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// parseJSONStrict decodes JSON like parseJSON but also returns the dotted
+// paths of any object keys that appear more than once
+func parseJSONStrict(data []byte) (map[string]interface{}, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var duplicates []string
+
+	val, err := decodeJSONValue(decoder, "", &duplicates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("failed to parse JSON: unexpected data after top-level value")
+	}
+
+	if val == nil {
+		return nil, duplicates, nil
+	}
+	result, ok := val.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to parse JSON: top-level value must be an object")
+	}
+	return result, duplicates, nil
+}
+
+func decodeJSONValue(decoder *json.Decoder, path string, duplicates *[]string) (interface{}, error) {
+	tok, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		// string, float64, bool, or nil
+		return tok, nil
+	}
+
+	switch delim {
+	case '{':
+		obj := make(map[string]interface{})
+		for decoder.More() {
+			keyTok, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyTok.(string)
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+
+			val, err := decodeJSONValue(decoder, childPath, duplicates)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := obj[key]; exists {
+				*duplicates = append(*duplicates, childPath)
+			}
+			obj[key] = val
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	case '[':
+		arr := []interface{}{}
+		for decoder.More() {
+			val, err := decodeJSONValue(decoder, fmt.Sprintf("%s.%d", path, len(arr)), duplicates)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// parseYAML decodes YAML into plain maps. yaml.v3 resolves aliases and
+// expands merge keys (`<<: *base` and `<<: [*a, *b]`) while decoding, so
+// merged fields become real keys and no literal "<<" key is left behind.
 func parseYAML(data []byte) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	if err := yaml.Unmarshal(data, &result); err != nil {
@@ -74,40 +378,279 @@ func parseTOML(data []byte) (map[string]interface{}, error) {
 	return result, nil
 }
 
+func parseHCL(data []byte) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := hcl.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse HCL: %w", err)
+	}
+	return normalizeHCL(result).(map[string]interface{}), nil
+}
+
+// normalizeHCL folds the []map[string]interface{} lists the HCL decoder
+// produces for blocks into plain nested maps, so `rate_limit { rpm = 100 }`
+// has the same shape as the equivalent JSON. Repeated blocks whose keys
+// collide are kept as a list.
+func normalizeHCL(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, nested := range v {
+			v[key] = normalizeHCL(nested)
+		}
+		return v
+	case []map[string]interface{}:
+		merged := make(map[string]interface{})
+		for _, block := range v {
+			for key, nested := range block {
+				if _, exists := merged[key]; exists {
+					list := make([]interface{}, len(v))
+					for i, b := range v {
+						list[i] = normalizeHCL(b)
+					}
+					return list
+				}
+				merged[key] = nested
+			}
+		}
+		return normalizeHCL(merged)
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeHCL(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// keyValueSyntax describes a line-oriented key/value format
+type keyValueSyntax struct {
+	name          string
+	separators    string // any of these characters splits key from value
+	comments      string // lines starting with any of these are skipped
+	continuations bool   // a trailing backslash joins the next line
+	sections      bool   // [section] headers nest the keys that follow
+}
+
+var (
+	propertiesSyntax = keyValueSyntax{name: "properties", separators: "=:", comments: "#!", continuations: true}
+	iniSyntax        = keyValueSyntax{name: "INI", separators: "=:", comments: ";#", sections: true}
+)
+
+// parseEnv reads a dotenv file. A leading "export" keyword is dropped, values
+// in single or double quotes may span lines, and "#" starts a comment only
+// at the start of a line or, outside quotes, after whitespace. Double-quoted
+// values unescape \n, \", and \\.
 func parseEnv(data []byte) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if rest := strings.TrimPrefix(line, "export"); rest != line && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
+		sep := strings.IndexByte(line, '=')
+		if sep < 0 {
+			continue
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			result[key] = stripEnvComment(value)
+			continue
+		}
+
+		// Quoted: read on until the closing quote, across lines if needed
+		quote := value[0]
+		raw := value[1:]
+		end := closingQuote(raw, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			raw += "\n" + lines[i]
+			end = closingQuote(raw, quote)
+		}
+		if end < 0 {
+			return nil, fmt.Errorf("failed to parse ENV: unterminated quoted value for %s", key)
+		}
+		raw = raw[:end]
+		if quote == '"' {
+			raw = envEscapes.Replace(raw)
+		}
+		result[key] = raw
+	}
+
+	return result, nil
+}
+
+var envEscapes = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`)
+
+// closingQuote returns the index of the quote that ends a value, skipping
+// backslash-escaped quotes in double-quoted values, or -1 if there is none
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// stripEnvComment removes a trailing " # comment" from an unquoted value
+func stripEnvComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// parseKeyValue reads KEY=VALUE style files. Keys and values are trimmed and
+// surrounding quotes are removed from values; lines without a separator are
+// ignored.
+func parseKeyValue(data []byte, syntax keyValueSyntax) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	target := result
 	scanner := bufio.NewScanner(strings.NewReader(string(data)))
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
 		// Skip empty lines and comments
-		if line == "" || strings.HasPrefix(line, "#") {
+		if line == "" || strings.ContainsAny(line[:1], syntax.comments) {
+			continue
+		}
+
+		for syntax.continuations && hasContinuation(line) && scanner.Scan() {
+			line = line[:len(line)-1] + strings.TrimSpace(scanner.Text())
+		}
+
+		if syntax.sections && strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section, ok := result[name].(map[string]interface{})
+			if !ok {
+				section = make(map[string]interface{})
+				result[name] = section
+			}
+			target = section
 			continue
 		}
 
 		// Parse KEY=VALUE
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
+		sep := strings.IndexAny(line, syntax.separators)
+		if sep < 0 {
 			continue
 		}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
 
 		// Remove quotes
 		value = strings.Trim(value, "\"'")
 
-		result[key] = value
+		target[key] = value
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to parse ENV: %w", err)
+		return nil, fmt.Errorf("failed to parse %s: %w", syntax.name, err)
 	}
 
 	return result, nil
 }
 
+// hasContinuation reports whether line ends in an unescaped backslash
+func hasContinuation(line string) bool {
+	backslashes := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+// expandEnvKeys nests flat env keys on "." and "__" separators, lowercasing
+// each segment so RATE_LIMIT__RPM=100 becomes {"rate_limit": {"rpm": "100"}}.
+// When a key is both a value and a parent (FOO=1, FOO__BAR=2), the nested
+// map wins so the result does not depend on map iteration order.
+func expandEnvKeys(flat map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for key, val := range flat {
+		var parts []string
+		for _, part := range strings.Split(strings.ReplaceAll(key, "__", "."), ".") {
+			if part != "" {
+				parts = append(parts, strings.ToLower(part))
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		current := result
+		for _, part := range parts[:len(parts)-1] {
+			nested, ok := current[part].(map[string]interface{})
+			if !ok {
+				nested = make(map[string]interface{})
+				current[part] = nested
+			}
+			current = nested
+		}
+
+		last := parts[len(parts)-1]
+		if _, isMap := current[last].(map[string]interface{}); !isMap {
+			current[last] = val
+		}
+	}
+
+	return result
+}
+
+var (
+	iniSection     = regexp.MustCompile(`^\[[^\[\]"{]+\]$`)
+	envAssignment  = regexp.MustCompile(`^(export[ \t]+)?[A-Za-z_][A-Za-z0-9_.]*=`)
+	tomlAssignment = regexp.MustCompile(`^[A-Za-z0-9_."'-]+\s*=\s*("|'|\[|\{|true\b|false\b|[-+]?\d)`)
+	iniAssignment  = regexp.MustCompile(`^[A-Za-z0-9_.-]+\s+=`)
+)
+
+// sniffFormat picks the parser for a .cfg or .conf file from its first
+// non-blank, non-comment line, returning that parser's extension. "{" means
+// JSON and a [section] header means INI. An assignment is TOML when its
+// value is a TOML literal, .env when written KEY=value, and INI otherwise.
+// Anything else is read as YAML.
+func sniffFormat(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		switch {
+		case line[0] == '{':
+			return ".json"
+		case strings.HasPrefix(line, "[["):
+			return ".toml"
+		case iniSection.MatchString(line):
+			return ".ini"
+		case line[0] == '[':
+			return ".json"
+		case envAssignment.MatchString(line):
+			return ".env"
+		case tomlAssignment.MatchString(line):
+			return ".toml"
+		case iniAssignment.MatchString(line):
+			return ".ini"
+		}
+		return ".yaml"
+	}
+	return ".yaml"
+}
+
 func autoDetectFormat(data []byte) (map[string]interface{}, error) {
 	// Try JSON first
 	if result, err := parseJSON(data); err == nil {
@@ -127,41 +670,294 @@ func autoDetectFormat(data []byte) (map[string]interface{}, error) {
 	return nil, fmt.Errorf("unable to auto-detect format")
 }
 
-// GetValue retrieves a value from nested config
+// EncodeConfig serializes config.Data back into the format implied by
+// config.FilePath. Comments and key order from the original file are not
+// preserved.
+func EncodeConfig(config *Config) ([]byte, error) {
+	ext := strings.ToLower(filepath.Ext(config.FilePath))
+
+	switch ext {
+	case ".json":
+		data, err := json.MarshalIndent(config.Data, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return append(data, '\n'), nil
+	case ".yaml", ".yml":
+		data, err := yaml.Marshal(config.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+		return data, nil
+	case ".toml":
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(config.Data); err != nil {
+			return nil, fmt.Errorf("failed to encode TOML: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("writing %s files is not supported", ext)
+	}
+}
+
+// GetValue retrieves a value from nested config. Numeric path segments index
+// into arrays, so "messages.0.role" reads the role of the first message.
 func (c *Config) GetValue(path string) (interface{}, bool) {
-	parts := strings.Split(path, ".")
-	current := c.Data
+	found, ok := c.lookupPath(path)
+	return found.value, ok
+}
+
+// GetValuesByPath returns every value matched by a dotted path in which a
+// "*" segment stands for every element of an array or every value of a map,
+// so "tools.*.api_key" collects the api_key of each tool. Map values are
+// returned in key order. Other segments are resolved as in GetValue.
+func (c *Config) GetValuesByPath(pattern string) []interface{} {
+	var values []interface{}
+	for _, found := range c.lookupPaths(pattern) {
+		values = append(values, found.value)
+	}
+	return values
+}
+
+// Select returns a config holding only the map at path (resolved like
+// GetValue), along with the JSONPath of that map. The map is shared with c,
+// so changes made through the returned config apply to c as well.
+func (c *Config) Select(path string) (*Config, string, error) {
+	found, ok := c.lookupPath(path)
+	if !ok {
+		return nil, "", fmt.Errorf("select path %q not found", path)
+	}
+	data, ok := found.value.(map[string]interface{})
+	if !ok {
+		return nil, "", fmt.Errorf("select path %q holds %s, not a map", path, valueType(found.value))
+	}
+
+	var duplicateKeys []string
+	for _, key := range c.DuplicateKeys {
+		if rest := strings.TrimPrefix(key, path+"."); rest != key {
+			duplicateKeys = append(duplicateKeys, rest)
+		}
+	}
+	return &Config{
+		Data:            data,
+		FilePath:        c.FilePath,
+		DuplicateKeys:   duplicateKeys,
+		CaseInsensitive: c.CaseInsensitive,
+	}, found.path, nil
+}
+
+// fieldValue is a config value together with the JSONPath of its node
+type fieldValue struct {
+	path  string
+	value interface{}
+}
+
+// lookupPath resolves a dotted path like GetValue and also returns the
+// JSONPath of the node it reached
+func (c *Config) lookupPath(path string) (fieldValue, bool) {
+	var current interface{} = c.Data
+	jsonPath := "$"
 
-	for i, part := range parts {
-		if val, ok := current[part]; ok {
-			if i == len(parts)-1 {
-				return val, true
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			key, val, ok := c.lookupKey(node, part)
+			if !ok {
+				return fieldValue{}, false
 			}
-			if nested, ok := val.(map[string]interface{}); ok {
-				current = nested
-			} else {
-				return nil, false
+			current = val
+			jsonPath = jsonPathKey(jsonPath, key)
+		case []interface{}:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return fieldValue{}, false
 			}
-		} else {
-			return nil, false
+			current = node[index]
+			jsonPath = jsonPathIndex(jsonPath, index)
+		default:
+			return fieldValue{}, false
+		}
+	}
+
+	return fieldValue{path: jsonPath, value: current}, true
+}
+
+// lookupPaths resolves a dotted path like GetValuesByPath and returns the
+// JSONPath of every node it reached
+func (c *Config) lookupPaths(pattern string) []fieldValue {
+	nodes := []fieldValue{{path: "$", value: c.Data}}
+	for _, part := range strings.Split(pattern, ".") {
+		var next []fieldValue
+		for _, node := range nodes {
+			switch v := node.value.(type) {
+			case map[string]interface{}:
+				if part == "*" {
+					for _, key := range sortedKeys(v) {
+						next = append(next, fieldValue{path: jsonPathKey(node.path, key), value: v[key]})
+					}
+				} else if key, val, ok := c.lookupKey(v, part); ok {
+					next = append(next, fieldValue{path: jsonPathKey(node.path, key), value: val})
+				}
+			case []interface{}:
+				if part == "*" {
+					for i, item := range v {
+						next = append(next, fieldValue{path: jsonPathIndex(node.path, i), value: item})
+					}
+				} else if index, err := strconv.Atoi(part); err == nil && index >= 0 && index < len(v) {
+					next = append(next, fieldValue{path: jsonPathIndex(node.path, index), value: v[index]})
+				}
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// lookupKey reads key from a map, falling back to a case- and
+// separator-insensitive search when c.CaseInsensitive is set. It returns the
+// key as spelled in the config.
+func (c *Config) lookupKey(node map[string]interface{}, key string) (string, interface{}, bool) {
+	if val, ok := node[key]; ok {
+		return key, val, true
+	}
+	if !c.CaseInsensitive {
+		return "", nil, false
+	}
+	for _, k := range sortedKeys(node) {
+		if foldFieldName(k) == foldFieldName(key) {
+			return k, node[k], true
+		}
+	}
+	return "", nil, false
+}
+
+var jsonPathIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// jsonPathKey appends an object key to a JSONPath, using bracket notation
+// for keys that are not plain identifiers
+func jsonPathKey(parent, key string) string {
+	if jsonPathIdentifier.MatchString(key) {
+		return parent + "." + key
+	}
+	return parent + "['" + strings.ReplaceAll(key, "'", "\\'") + "']"
+}
+
+// jsonPathIndex appends an array index to a JSONPath
+func jsonPathIndex(parent string, index int) string {
+	return parent + "[" + strconv.Itoa(index) + "]"
+}
+
+// JSONPointer converts a path in the form used by Finding.Path ($.a.b,
+// $['x-key'], $.items[0]) to an RFC 6901 JSON Pointer (/a/b, /x-key,
+// /items/0), escaping "~" as "~0" and "/" as "~1". It returns "" for paths it
+// does not recognize.
+func JSONPointer(path string) string {
+	if !strings.HasPrefix(path, "$") {
+		return ""
+	}
+	var pointer strings.Builder
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+	rest := path[1:]
+	for rest != "" {
+		var token string
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			token, rest = rest[1:end+1], rest[end+1:]
+		case strings.HasPrefix(rest, "['"):
+			var key strings.Builder
+			i := 2
+			for ; i < len(rest) && !strings.HasPrefix(rest[i:], "']"); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) && rest[i+1] == '\'' {
+					i++
+				}
+				key.WriteByte(rest[i])
+			}
+			if i >= len(rest) {
+				return ""
+			}
+			token, rest = key.String(), rest[i+2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return ""
+			}
+			token, rest = rest[1:end], rest[end+1:]
+		default:
+			return ""
+		}
+		pointer.WriteString("/" + escaper.Replace(token))
+	}
+	return pointer.String()
+}
+
+func sortedKeys(data map[string]interface{}) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldMatcher returns a function reporting whether a key names field. A
+// field containing * or ? is a shell-style wildcard matched against the
+// whole key, so *_key matches api_key and openai_key but not keyboard.
+func (c *Config) fieldMatcher(field string) func(string) bool {
+	fold := func(name string) string { return name }
+	if c.CaseInsensitive {
+		fold = foldFieldName
+	}
+	if strings.ContainsAny(field, "*?") {
+		pattern := wildcardPattern(fold(field))
+		return func(key string) bool { return pattern.MatchString(fold(key)) }
+	}
+	folded := fold(field)
+	return func(key string) bool { return fold(key) == folded }
+}
+
+// wildcardPattern compiles a field name where * matches any run of
+// characters and ? matches exactly one into an anchored regexp
+func wildcardPattern(field string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range field {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
 		}
 	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
 
-	return nil, false
+var fieldSeparators = strings.NewReplacer("_", "", "-", "")
+
+// foldFieldName lowercases a field name and drops "_" and "-" so that
+// API_KEY, api_key, api-key, and apiKey compare equal
+func foldFieldName(name string) string {
+	return fieldSeparators.Replace(strings.ToLower(name))
 }
 
 // HasField checks if a field exists anywhere in the config
 func (c *Config) HasField(field string) bool {
-	return hasFieldRecursive(c.Data, field)
+	return hasFieldRecursive(c.Data, c.fieldMatcher(field))
 }
 
-func hasFieldRecursive(data map[string]interface{}, field string) bool {
+func hasFieldRecursive(data map[string]interface{}, matches func(string) bool) bool {
 	for key, val := range data {
-		if key == field {
+		if matches(key) {
 			return true
 		}
 		if nested, ok := val.(map[string]interface{}); ok {
-			if hasFieldRecursive(nested, field) {
+			if hasFieldRecursive(nested, matches) {
 				return true
 			}
 		}
@@ -172,17 +968,29 @@ func hasFieldRecursive(data map[string]interface{}, field string) bool {
 // GetAllFieldValues returns all values for a given field name
 func (c *Config) GetAllFieldValues(field string) []interface{} {
 	var values []interface{}
-	collectFieldValues(c.Data, field, &values)
+	for _, found := range c.findFieldValues(field) {
+		values = append(values, found.value)
+	}
 	return values
 }
 
-func collectFieldValues(data map[string]interface{}, field string, values *[]interface{}) {
-	for key, val := range data {
-		if key == field {
-			*values = append(*values, val)
+// findFieldValues returns every value stored under field in nested objects,
+// in key order, with the JSONPath of each
+func (c *Config) findFieldValues(field string) []fieldValue {
+	var values []fieldValue
+	collectFieldValues(c.Data, "$", c.fieldMatcher(field), &values)
+	return values
+}
+
+func collectFieldValues(data map[string]interface{}, prefix string, matches func(string) bool, values *[]fieldValue) {
+	for _, key := range sortedKeys(data) {
+		val := data[key]
+		path := jsonPathKey(prefix, key)
+		if matches(key) {
+			*values = append(*values, fieldValue{path: path, value: val})
 		}
 		if nested, ok := val.(map[string]interface{}); ok {
-			collectFieldValues(nested, field, values)
+			collectFieldValues(nested, path, matches, values)
 		}
 	}
 }
@@ -195,8 +1003,8 @@ func (c *Config) GetAllContent() string {
 }
 
 func collectContent(data map[string]interface{}, content *strings.Builder) {
-	for _, val := range data {
-		switch v := val.(type) {
+	for _, key := range sortedKeys(data) {
+		switch v := data[key].(type) {
 		case string:
 			content.WriteString(v)
 			content.WriteString(" ")
@@ -3,6 +3,9 @@ package scanner
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -42,6 +45,13 @@ func TestParseConfigFile(t *testing.T) {
 			wantErr:  false,
 			wantKeys: []string{"MODEL", "TEMPERATURE", "MAX_TOKENS"},
 		},
+		{
+			name:     "valid hcl",
+			filename: "gateway.tf",
+			content:  "model = \"gpt-4\"\ntemperature = 0.7\nmax_tokens = 1000",
+			wantErr:  false,
+			wantKeys: []string{"model", "temperature", "max_tokens"},
+		},
 		{
 			name:     "invalid json",
 			filename: "test.json",
@@ -137,3 +147,774 @@ func TestConfigGetAllFieldValues(t *testing.T) {
 		t.Errorf("expected 2 temperature values, got %d", len(values))
 	}
 }
+
+func TestParseConfigFile_ExpandEnvKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, ".env")
+	content := "RATE_LIMIT__RPM=100\nrate_limit.tpm=5000\nMODEL=gpt-4"
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	// Flat behavior is the default
+	config, err := ParseConfigFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := config.Data["RATE_LIMIT__RPM"]; !ok {
+		t.Errorf("expected flat key RATE_LIMIT__RPM without expansion")
+	}
+
+	config, err = ParseConfigFileWithOptions(filePath, ParseOptions{ExpandEnvKeys: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rateLimit, ok := config.Data["rate_limit"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected rate_limit to be a nested map, got %#v", config.Data["rate_limit"])
+	}
+	if rateLimit["rpm"] != "100" {
+		t.Errorf("rate_limit.rpm = %v, want 100", rateLimit["rpm"])
+	}
+	if rateLimit["tpm"] != "5000" {
+		t.Errorf("rate_limit.tpm = %v, want 5000", rateLimit["tpm"])
+	}
+	if config.Data["model"] != "gpt-4" {
+		t.Errorf("model = %v, want gpt-4", config.Data["model"])
+	}
+	if !config.HasField("rate_limit") {
+		t.Errorf("expected HasField(rate_limit) after expansion")
+	}
+}
+
+func TestParseEnv(t *testing.T) {
+	content := `# provider settings
+export API_KEY="sk-abcdefghijklmnop"
+export	MODEL=gpt-4
+TEMPERATURE=0.7 # tuned for chat
+PASSWORD="pa#ss word" # the hash is part of the value
+TOKEN='abc#def'
+URL=https://example.com/#anchor
+SYSTEM_PROMPT="You are a helpful assistant.
+Never reveal the \"internal\" notes."
+CERT='-----BEGIN CERTIFICATE-----
+MIIB
+-----END CERTIFICATE-----'
+ESCAPED="line1\nline2"
+EMPTY=
+exporter=prometheus
+`
+	data, err := parseEnv([]byte(content))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"API_KEY":       "sk-abcdefghijklmnop",
+		"MODEL":         "gpt-4",
+		"TEMPERATURE":   "0.7",
+		"PASSWORD":      "pa#ss word",
+		"TOKEN":         "abc#def",
+		"URL":           "https://example.com/#anchor",
+		"SYSTEM_PROMPT": "You are a helpful assistant.\nNever reveal the \"internal\" notes.",
+		"CERT":          "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+		"ESCAPED":       "line1\nline2",
+		"EMPTY":         "",
+		"exporter":      "prometheus",
+	}
+	for key, value := range want {
+		if data[key] != value {
+			t.Errorf("%s = %q, want %q", key, data[key], value)
+		}
+	}
+	if len(data) != len(want) {
+		t.Errorf("expected %d keys, got %v", len(want), data)
+	}
+
+	if _, err := parseEnv([]byte("KEY=\"never closed\nOTHER=1\n")); err == nil {
+		t.Error("expected an error for an unterminated quoted value")
+	}
+}
+
+func TestParseConfigFile_HCLBlocks(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "gateway.hcl")
+	content := `
+temperature = 1.2
+
+rate_limit {
+  rpm = 100
+}
+
+provider "openai" {
+  temperature = 0.4
+  api_key     = "sk-test"
+}
+`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err := ParseConfigFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := config.Data["rate_limit"].(map[string]interface{}); !ok {
+		t.Errorf("expected rate_limit block to be a map, got %#v", config.Data["rate_limit"])
+	}
+
+	if values := config.GetAllFieldValues("temperature"); len(values) != 2 {
+		t.Errorf("expected 2 temperature values, got %d", len(values))
+	}
+	if values := config.GetAllFieldValues("rpm"); len(values) != 1 {
+		t.Errorf("expected 1 rpm value, got %d", len(values))
+	}
+	if val, ok := config.GetValue("provider.openai.api_key"); !ok || val != "sk-test" {
+		t.Errorf("GetValue(provider.openai.api_key) = %v, %v", val, ok)
+	}
+}
+
+func TestParseConfigFile_YAMLMergeKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "models.yaml")
+	content := `
+defaults: &defaults
+  temperature: 1.8
+  top_p: 0.9
+limits: &limits
+  max_tokens: 512
+production:
+  <<: [*defaults, *limits]
+  model: gpt-4o
+  top_p: 0.5
+replicas:
+  - <<: *defaults
+    name: canary
+`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err := ParseConfigFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if config.HasField("<<") {
+		t.Error("merge key left a literal << field")
+	}
+	if val, ok := config.GetValue("production.temperature"); !ok || val != 1.8 {
+		t.Errorf("GetValue(production.temperature) = %v, %v", val, ok)
+	}
+	if val, ok := config.GetValue("production.max_tokens"); !ok || val != 512 {
+		t.Errorf("GetValue(production.max_tokens) = %v, %v", val, ok)
+	}
+	// Keys set alongside the merge win over merged values
+	if val, ok := config.GetValue("production.top_p"); !ok || val != 0.5 {
+		t.Errorf("GetValue(production.top_p) = %v, %v", val, ok)
+	}
+	if val, ok := config.GetValue("replicas.0.temperature"); !ok || val != 1.8 {
+		t.Errorf("GetValue(replicas.0.temperature) = %v, %v", val, ok)
+	}
+
+	rule := Rule{
+		ID:    "TEMP_001",
+		Check: Check{Type: "numeric_range", Path: "production.temperature", Max: 1.0},
+	}
+	if finding := firstFinding(rule, config); finding == nil {
+		t.Error("expected the merged-in temperature to be flagged")
+	}
+}
+
+func TestConfigGetValue(t *testing.T) {
+	config := &Config{
+		Data: map[string]interface{}{
+			"temperature": 0.7,
+			"settings": map[string]interface{}{
+				"temperature": 0.9,
+			},
+			"messages": []interface{}{
+				map[string]interface{}{"role": "system"},
+				map[string]interface{}{"role": "user"},
+			},
+			"stop": []interface{}{"END", "STOP"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{"top level", "temperature", 0.7, true},
+		{"nested map", "settings.temperature", 0.9, true},
+		{"array element field", "messages.0.role", "system", true},
+		{"second array element", "messages.1.role", "user", true},
+		{"array of strings", "stop.1", "STOP", true},
+		{"negative index", "messages.-1.role", nil, false},
+		{"index out of range", "messages.2.role", nil, false},
+		{"non-numeric index", "messages.first.role", nil, false},
+		{"index into scalar", "temperature.0", nil, false},
+		{"missing key", "settings.top_p", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := config.GetValue(tt.path)
+			if ok != tt.wantOK {
+				t.Fatalf("GetValue(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			}
+			if got != tt.want {
+				t.Errorf("GetValue(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigGetValuesByPath(t *testing.T) {
+	config := &Config{
+		Data: map[string]interface{}{
+			"tools": []interface{}{
+				map[string]interface{}{"name": "search", "api_key": "k1"},
+				map[string]interface{}{"name": "calculator"},
+				map[string]interface{}{"name": "weather", "api_key": "k3"},
+			},
+			"providers": map[string]interface{}{
+				"openai":    map[string]interface{}{"api_key": "oa", "timeout": 30},
+				"anthropic": map[string]interface{}{"api_key": "an"},
+			},
+			"messages": []interface{}{
+				map[string]interface{}{"role": "system"},
+				map[string]interface{}{"role": "user"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []interface{}
+	}{
+		{"wildcard over array of objects", "tools.*.api_key", []interface{}{"k1", "k3"}},
+		{"wildcard over map of objects", "providers.*.api_key", []interface{}{"an", "oa"}},
+		{"wildcard as last segment", "providers.openai.*", []interface{}{"oa", 30}},
+		{"consecutive wildcards", "*.*.role", []interface{}{"system", "user"}},
+		{"exact path", "messages.1.role", []interface{}{"user"}},
+		{"no match", "tools.*.token", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := config.GetValuesByPath(tt.pattern)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetValuesByPath(%q) = %v, want %v", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	// Rule paths use the same wildcards
+	rule := Rule{ID: "KEY_001", Check: Check{Type: "pattern_match", Path: "tools.*.api_key", Patterns: []string{"^k3$"}}}
+	finding := firstFinding(rule, config)
+	if finding == nil || finding.Path != "$.tools[2].api_key" {
+		t.Errorf("expected a finding at $.tools[2].api_key, got %+v", finding)
+	}
+}
+
+func TestParseConfigFile_StrictJSONDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.json")
+	content := `{
+		"temperature": 0.2,
+		"settings": {"top_p": 0.5, "top_p": 0.99},
+		"tools": [{"name": "a", "name": "b"}],
+		"temperature": 1.9
+	}`
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err := ParseConfigFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.DuplicateKeys) != 0 {
+		t.Errorf("duplicates should only be tracked in strict mode, got %v", config.DuplicateKeys)
+	}
+
+	config, err = ParseConfigFileWithOptions(filePath, ParseOptions{StrictJSON: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]bool{"temperature": true, "settings.top_p": true, "tools.0.name": true}
+	if len(config.DuplicateKeys) != len(want) {
+		t.Fatalf("DuplicateKeys = %v, want %d entries", config.DuplicateKeys, len(want))
+	}
+	for _, key := range config.DuplicateKeys {
+		if !want[key] {
+			t.Errorf("unexpected duplicate key %q", key)
+		}
+	}
+
+	// Last value wins, matching encoding/json
+	if config.Data["temperature"] != 1.9 {
+		t.Errorf("temperature = %v, want 1.9", config.Data["temperature"])
+	}
+
+	for _, bad := range []string{`{"a": 1`, `[1, 2]`, `{"a": 1} {"b": 2}`} {
+		if err := os.WriteFile(filePath, []byte(bad), 0644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		if _, err := ParseConfigFileWithOptions(filePath, ParseOptions{StrictJSON: true}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestConfig_CaseInsensitive(t *testing.T) {
+	data := map[string]interface{}{
+		"API_KEY": "sk-one",
+		"providers": map[string]interface{}{
+			"apiKey":    "sk-two",
+			"api-key":   "sk-three",
+			"RateLimit": map[string]interface{}{"RPM": 100},
+		},
+	}
+
+	strict := &Config{Data: data}
+	if strict.HasField("api_key") {
+		t.Error("default matching should be strict")
+	}
+	if values := strict.GetAllFieldValues("api_key"); len(values) != 0 {
+		t.Errorf("expected no strict matches, got %v", values)
+	}
+
+	folded := &Config{Data: data, CaseInsensitive: true}
+	if !folded.HasField("api_key") {
+		t.Error("expected HasField(api_key) to match casing variants")
+	}
+	if values := folded.GetAllFieldValues("api_key"); len(values) != 3 {
+		t.Errorf("expected API_KEY, apiKey, and api-key to match, got %v", values)
+	}
+	if folded.HasField("apikeys") {
+		t.Error("folding should not match different names")
+	}
+	if val, ok := folded.GetValue("providers.rate_limit.rpm"); !ok || val != 100 {
+		t.Errorf("GetValue(providers.rate_limit.rpm) = %v, %v", val, ok)
+	}
+}
+
+func TestConfig_WildcardFields(t *testing.T) {
+	data := map[string]interface{}{
+		"api_key":  "sk-one",
+		"keyboard": "qwerty",
+		"providers": map[string]interface{}{
+			"openai_key": "sk-two",
+			"OPENAI_KEY": "sk-three",
+		},
+		"model": "gpt-4o",
+	}
+
+	tests := []struct {
+		field           string
+		caseInsensitive bool
+		want            []string
+	}{
+		{"*_key", false, []string{"$.api_key", "$.providers.openai_key"}},
+		{"api_*", false, []string{"$.api_key"}},
+		{"mod?l", false, []string{"$.model"}},
+		{"mod?", false, nil},
+		{"*_KEY", false, []string{"$.providers.OPENAI_KEY"}},
+		{"openai*", true, []string{"$.providers.OPENAI_KEY", "$.providers.openai_key"}},
+		{"api_key", false, []string{"$.api_key"}},
+	}
+
+	for _, tt := range tests {
+		config := &Config{Data: data, CaseInsensitive: tt.caseInsensitive}
+		var got []string
+		for _, found := range config.findFieldValues(tt.field) {
+			got = append(got, found.path)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("findFieldValues(%q) paths = %v, want %v", tt.field, got, tt.want)
+		}
+		if config.HasField(tt.field) != (len(tt.want) > 0) {
+			t.Errorf("HasField(%q) = %v, want %v", tt.field, !(len(tt.want) > 0), len(tt.want) > 0)
+		}
+	}
+}
+
+func TestParseConfigFile_MaxFileSize(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Sparse file one byte over the default limit
+	huge := filepath.Join(tmpDir, "huge.json")
+	f, err := os.Create(huge)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if err := f.Truncate(DefaultMaxFileSize + 1); err != nil {
+		t.Fatalf("failed to size test file: %v", err)
+	}
+	f.Close()
+
+	_, err = ParseConfigFile(huge)
+	if err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Errorf("expected size limit error, got %v", err)
+	}
+
+	small := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(small, []byte(`{"temperature": 0.7, "model": "gpt-4o"}`), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	if _, err := ParseConfigFileWithOptions(small, ParseOptions{MaxFileSize: 16}); err == nil {
+		t.Error("expected an error with a 16 byte limit")
+	}
+	if _, err := ParseConfigFileWithOptions(small, ParseOptions{MaxFileSize: -1}); err != nil {
+		t.Errorf("unexpected error with the limit disabled: %v", err)
+	}
+}
+
+func TestParseConfigFile_JSONComments(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `{
+	// Sampling
+	"temperature": 0.7, /* tuned for support */
+	"base_url": "https://api.example.com/v1", // not a comment inside strings
+	"note": "a /* b */ c",
+	"stop": ["\n", "END",],
+}`
+
+	jsoncPath := filepath.Join(tmpDir, "settings.jsonc")
+	jsonPath := filepath.Join(tmpDir, "settings.json")
+	for _, path := range []string{jsoncPath, jsonPath} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+
+	config, err := ParseConfigFile(jsoncPath)
+	if err != nil {
+		t.Fatalf("unexpected error for .jsonc: %v", err)
+	}
+	if config.Data["temperature"] != 0.7 {
+		t.Errorf("temperature = %v, want 0.7", config.Data["temperature"])
+	}
+	if config.Data["base_url"] != "https://api.example.com/v1" {
+		t.Errorf("base_url = %v, string contents must be preserved", config.Data["base_url"])
+	}
+	if config.Data["note"] != "a /* b */ c" {
+		t.Errorf("note = %v, string contents must be preserved", config.Data["note"])
+	}
+	if stop, ok := config.Data["stop"].([]interface{}); !ok || len(stop) != 2 {
+		t.Errorf("stop = %v, want two entries", config.Data["stop"])
+	}
+
+	if _, err := ParseConfigFile(jsonPath); err == nil {
+		t.Error("expected .json with comments to fail without TolerantJSON")
+	}
+	if _, err := ParseConfigFileWithOptions(jsonPath, ParseOptions{TolerantJSON: true}); err != nil {
+		t.Errorf("unexpected error with TolerantJSON: %v", err)
+	}
+}
+
+func TestParseConfigFile_INIAndProperties(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	iniPath := filepath.Join(tmpDir, "gateway.ini")
+	iniContent := `; LLM gateway
+model = gpt-4o
+
+[sampling]
+temperature = 0.7
+# comment inside a section
+top_p: 0.9
+
+[auth]
+api_key = "sk-test"
+`
+	if err := os.WriteFile(iniPath, []byte(iniContent), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err := ParseConfigFile(iniPath)
+	if err != nil {
+		t.Fatalf("unexpected error for .ini: %v", err)
+	}
+	if config.Data["model"] != "gpt-4o" {
+		t.Errorf("model = %v, want gpt-4o", config.Data["model"])
+	}
+	for path, want := range map[string]string{
+		"sampling.temperature": "0.7",
+		"sampling.top_p":       "0.9",
+		"auth.api_key":         "sk-test",
+	} {
+		if got, _ := config.GetValue(path); got != want {
+			t.Errorf("%s = %v, want %q", path, got, want)
+		}
+	}
+
+	propsPath := filepath.Join(tmpDir, "llm.properties")
+	propsContent := `! Java-style comment
+llm.model: gpt-4o
+llm.system_prompt = You are a helpful \
+    assistant for \
+    billing questions
+llm.path = C:\\temp\\
+llm.temperature=0.2
+`
+	if err := os.WriteFile(propsPath, []byte(propsContent), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err = ParseConfigFile(propsPath)
+	if err != nil {
+		t.Fatalf("unexpected error for .properties: %v", err)
+	}
+	want := map[string]string{
+		"llm.model":         "gpt-4o",
+		"llm.system_prompt": "You are a helpful assistant for billing questions",
+		"llm.path":          `C:\\temp\\`,
+		"llm.temperature":   "0.2",
+	}
+	for key, value := range want {
+		if config.Data[key] != value {
+			t.Errorf("%s = %q, want %q", key, config.Data[key], value)
+		}
+	}
+}
+
+func TestNormalizeConfig_YAMLAndEnvAgree(t *testing.T) {
+	tmpDir := t.TempDir()
+	yamlPath := filepath.Join(tmpDir, "config.yaml")
+	envPath := filepath.Join(tmpDir, "config.env")
+	if err := os.WriteFile(yamlPath, []byte("temperature: 1.5\nstreaming: true\nmodel: gpt-4o\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if err := os.WriteFile(envPath, []byte("temperature=1.5\nstreaming=TRUE\nmodel=gpt-4o\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	rules := []Rule{
+		{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Min: 0, Max: 1}},
+		{ID: "TYPE_001", Check: Check{Type: "field_type", Field: "streaming", ExpectedType: "string"}},
+	}
+
+	fired := func(path string, opts ParseOptions) map[string]bool {
+		config, err := ParseConfigFileWithOptions(path, opts)
+		if err != nil {
+			t.Fatalf("unexpected error for %s: %v", path, err)
+		}
+		got := make(map[string]bool)
+		for _, rule := range rules {
+			got[rule.ID] = firstFinding(rule, config) != nil
+		}
+		return got
+	}
+
+	// Without normalization the env file is all strings
+	if got := fired(envPath, ParseOptions{}); got["TEMP_001"] || got["TYPE_001"] {
+		t.Errorf("expected string env values to skip both rules, got %v", got)
+	}
+
+	opts := ParseOptions{NormalizeValues: true}
+	yamlFired := fired(yamlPath, opts)
+	envFired := fired(envPath, opts)
+	for _, rule := range rules {
+		if yamlFired[rule.ID] != envFired[rule.ID] {
+			t.Errorf("%s: yaml fired = %v, env fired = %v", rule.ID, yamlFired[rule.ID], envFired[rule.ID])
+		}
+	}
+	if !envFired["TEMP_001"] || !envFired["TYPE_001"] {
+		t.Errorf("expected both rules to fire after normalization, got %v", envFired)
+	}
+
+	config, _ := ParseConfigFileWithOptions(envPath, opts)
+	if config.Data["model"] != "gpt-4o" {
+		t.Errorf("non-numeric strings should be unchanged, got %v", config.Data["model"])
+	}
+}
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$", ""},
+		{"$.temperature", "/temperature"},
+		{"$.rate_limit.rpm", "/rate_limit/rpm"},
+		{"$.tools[0].api_key", "/tools/0/api_key"},
+		{"$.matrix[1][2]", "/matrix/1/2"},
+		{"$['x-headers'].auth", "/x-headers/auth"},
+		{"$['a/b']['m~n']", "/a~1b/m~0n"},
+		{"$['it\\'s']", "/it's"},
+		{"temperature", ""},
+		{"$['unterminated", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := JSONPointer(tt.path); got != tt.want {
+				t.Errorf("JSONPointer(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+
+	// Paths built while walking a config round-trip through the helper
+	path := jsonPathIndex(jsonPathKey(jsonPathKey("$", "services"), "a/b~c"), 3)
+	if got := JSONPointer(path); got != "/services/a~1b~0c/3" {
+		t.Errorf("JSONPointer(%q) = %q", path, got)
+	}
+}
+
+func TestConfig_Select(t *testing.T) {
+	config := &Config{
+		Data: map[string]interface{}{
+			"services": map[string]interface{}{
+				"llm":   map[string]interface{}{"temperature": 1.5},
+				"cache": map[string]interface{}{"ttl": 60.0},
+			},
+			"name":    "app",
+			"workers": []interface{}{map[string]interface{}{"id": "a"}},
+		},
+		DuplicateKeys: []string{"services.llm.temperature", "name"},
+	}
+
+	selected, path, err := config.Select("services.llm")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "$.services.llm" {
+		t.Errorf("expected path $.services.llm, got %q", path)
+	}
+	if selected.HasField("ttl") || !selected.HasField("temperature") {
+		t.Errorf("expected only the llm block, got %v", selected.Data)
+	}
+	if len(selected.DuplicateKeys) != 1 || selected.DuplicateKeys[0] != "temperature" {
+		t.Errorf("expected duplicate keys inside the block relative to it, got %v", selected.DuplicateKeys)
+	}
+
+	// The selected map is shared with the original config
+	selected.Data["temperature"] = 0.5
+	if value, _ := config.GetValue("services.llm.temperature"); value != 0.5 {
+		t.Errorf("expected changes to reach the original config, got %v", value)
+	}
+
+	if _, path, err := config.Select("workers.0"); err != nil || path != "$.workers[0]" {
+		t.Errorf("expected to select an array element, got %q, %v", path, err)
+	}
+
+	for _, bad := range []struct{ path, message string }{
+		{"services.missing", "not found"},
+		{"name", "holds string, not a map"},
+		{"workers", "holds array, not a map"},
+	} {
+		if _, _, err := config.Select(bad.path); err == nil || !strings.Contains(err.Error(), bad.message) {
+			t.Errorf("Select(%q): expected an error containing %q, got %v", bad.path, bad.message, err)
+		}
+	}
+}
+
+func TestParseConfigFile_SniffedFormats(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		wantFormat string
+		path       string
+		want       interface{}
+	}{
+		{
+			name:       "JSON object",
+			file:       "llm.cfg",
+			content:    "\n  {\"sampling\": {\"temperature\": 0.7}}\n",
+			wantFormat: ".json",
+			path:       "sampling.temperature",
+			want:       0.7,
+		},
+		{
+			name:       "INI section",
+			file:       "gateway.conf",
+			content:    "; gateway settings\n[sampling]\ntemperature = 0.7\n",
+			wantFormat: ".ini",
+			path:       "sampling.temperature",
+			want:       "0.7",
+		},
+		{
+			name:       "TOML assignment",
+			file:       "llm.conf",
+			content:    "# model settings\nmodel = \"gpt-4o\"\n\n[sampling]\ntemperature = 0.7\n",
+			wantFormat: ".toml",
+			path:       "sampling.temperature",
+			want:       0.7,
+		},
+		{
+			name:       "TOML array of tables",
+			file:       "models.cfg",
+			content:    "[[models]]\nname = \"gpt-4o\"\n",
+			wantFormat: ".toml",
+		},
+		{
+			name:       "bare INI assignment",
+			file:       "llm.cfg",
+			content:    "model = gpt-4o\n",
+			wantFormat: ".ini",
+			path:       "model",
+			want:       "gpt-4o",
+		},
+		{
+			name:       "dotenv",
+			file:       "llm.conf",
+			content:    "export OPENAI_MODEL=gpt-4o\nTEMPERATURE=0.7\n",
+			wantFormat: ".env",
+			path:       "TEMPERATURE",
+			want:       "0.7",
+		},
+		{
+			name:       "YAML",
+			file:       "llm.cfg",
+			content:    "# model settings\nsampling:\n  temperature: 0.7\n",
+			wantFormat: ".yaml",
+			path:       "sampling.temperature",
+			want:       0.7,
+		},
+	}
+
+	tmpDir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffFormat([]byte(tt.content)); got != tt.wantFormat {
+				t.Fatalf("sniffFormat() = %q, want %q", got, tt.wantFormat)
+			}
+			if tt.path == "" {
+				return
+			}
+
+			path := filepath.Join(tmpDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+			config, err := ParseConfigFile(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got, _ := config.GetValue(tt.path); got != tt.want {
+				t.Errorf("%s = %v (%T), want %v", tt.path, got, got, tt.want)
+			}
+		})
+	}
+
+	// A broken JSON .conf reports the JSON error instead of parsing as YAML
+	path := filepath.Join(tmpDir, "broken.conf")
+	if err := os.WriteFile(path, []byte(`{"temperature": 0.7,}`), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := ParseConfigFile(path); err == nil {
+		t.Error("expected a JSON parse error for broken.conf")
+	}
+}
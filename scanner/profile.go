@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// validateProfiles checks that every profile's file globs are well formed
+// and its severity overrides name real severities, so a typo such as
+// "to: lwo" fails loading instead of producing findings no threshold sees
+func validateProfiles(rules RulesFile) error {
+	for _, profile := range rules.Profiles {
+		for _, glob := range profile.Files {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return fmt.Errorf("profile %s has invalid files glob %q: %w", profile.Name, glob, err)
+			}
+		}
+		for _, override := range profile.Severity {
+			if !IsValidSeverity(override.To) {
+				return fmt.Errorf("profile %s has invalid severity override to %q", profile.Name, override.To)
+			}
+			if override.From != "" && !IsValidSeverity(override.From) {
+				return fmt.Errorf("profile %s has invalid severity override from %q", profile.Name, override.From)
+			}
+		}
+	}
+	return nil
+}
+
+// profileFor returns the first profile whose globs match filePath, or nil.
+// Globs are tried against both the base name and the slash-separated path.
+func (s *Scanner) profileFor(filePath string) *Profile {
+	base := filepath.Base(filePath)
+	slashed := filepath.ToSlash(filePath)
+
+	for i := range s.rules.Profiles {
+		profile := &s.rules.Profiles[i]
+		for _, glob := range profile.Files {
+			if ok, _ := filepath.Match(glob, base); ok {
+				return profile
+			}
+			if ok, _ := filepath.Match(glob, slashed); ok {
+				return profile
+			}
+		}
+	}
+	return nil
+}
+
+// allows reports whether the profile lets a rule run
+func (p *Profile) allows(rule Rule) bool {
+	if p == nil {
+		return true
+	}
+	if len(p.Rules) > 0 && !containsFold(p.Rules, rule.ID) {
+		return false
+	}
+	return !containsFold(p.Disable, rule.ID)
+}
+
+// apply rewrites the finding's severity using the first matching override
+func (p *Profile) apply(finding *Finding) {
+	if p == nil {
+		return
+	}
+	for _, override := range p.Severity {
+		if len(override.Rules) > 0 && !containsFold(override.Rules, finding.RuleID) {
+			continue
+		}
+		if override.Category != "" && !strings.EqualFold(override.Category, finding.Category) {
+			continue
+		}
+		if override.From != "" && !strings.EqualFold(override.From, finding.Severity) {
+			continue
+		}
+		finding.Severity = strings.ToUpper(override.To)
+		return
+	}
+}
+
+func containsFold(values []string, want string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// RecommendationData is what a rule's recommendation_template can refer to
+type RecommendationData struct {
+	RuleID string
+	// Field is the last named segment of Location, such as api_key for
+	// tools.0.api_key
+	Field    string
+	Location string
+	Path     string
+	// Value is the finding's evidence, redacted unless the check sets
+	// redact: false. It is empty for checks that report no evidence.
+	Value string
+}
+
+var recommendationFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+func parseRecommendationTemplate(text string) (*template.Template, error) {
+	return template.New("recommendation").Funcs(recommendationFuncs).Parse(text)
+}
+
+// validateRecommendationTemplates parses and test-renders every
+// recommendation_template, so mistakes such as unknown fields surface when
+// the rules load rather than per finding
+func validateRecommendationTemplates(rules RulesFile) error {
+	for _, rule := range rules.Rules {
+		if rule.RecommendationTemplate == "" {
+			continue
+		}
+		tmpl, err := parseRecommendationTemplate(rule.RecommendationTemplate)
+		if err == nil {
+			err = tmpl.Execute(io.Discard, RecommendationData{})
+		}
+		if err != nil {
+			return fmt.Errorf("rule %s has an invalid recommendation_template: %w", rule.ID, err)
+		}
+	}
+	return nil
+}
+
+// renderRecommendation fills the rule's recommendation_template for a
+// finding. It returns the static recommendation when there is no template
+// or the template fails to render.
+func renderRecommendation(rule Rule, finding Finding) string {
+	if rule.RecommendationTemplate == "" {
+		return rule.Recommendation
+	}
+	tmpl, err := parseRecommendationTemplate(rule.RecommendationTemplate)
+	if err != nil {
+		return rule.Recommendation
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, RecommendationData{
+		RuleID:   finding.RuleID,
+		Field:    fieldName(finding.Location),
+		Location: finding.Location,
+		Path:     finding.Path,
+		Value:    finding.Evidence,
+	})
+	if err != nil {
+		return rule.Recommendation
+	}
+	return rendered.String()
+}
+
+// fieldName returns the last non-index segment of a dotted location. Lists
+// of fields, as reported for missing fields, are returned unchanged.
+func fieldName(location string) string {
+	if strings.Contains(location, ", ") {
+		return location
+	}
+	segments := strings.Split(location, ".")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if _, err := strconv.Atoi(segments[i]); err != nil {
+			return segments[i]
+		}
+	}
+	return location
+}
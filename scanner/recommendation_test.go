@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckRule_RecommendationTemplate(t *testing.T) {
+	secretRule := Rule{
+		ID:             "SECRET_001",
+		Recommendation: "Remove the API key.",
+		Check: Check{
+			Type:     "pattern_match",
+			Patterns: []string{"sk-[a-zA-Z0-9]{10,}"},
+		},
+		Fields: []string{"api_key"},
+	}
+
+	tests := []struct {
+		name     string
+		rule     Rule
+		template string
+		config   map[string]interface{}
+		expected string
+	}{
+		{
+			name:     "field and redacted value",
+			rule:     secretRule,
+			template: "Replace `{{.Field}}: {{.Value}}` with `{{.Field}}: ${OPENAI_{{upper .Field}}}` and add the value to your secret store.",
+			config:   map[string]interface{}{"api_key": "sk-abcdefghijklmnop"},
+			expected: "Replace `api_key: " + RedactValue("sk-abcdefghijklmnop") + "` with `api_key: ${OPENAI_API_KEY}` and add the value to your secret store.",
+		},
+		{
+			name:     "array element",
+			rule:     secretRule,
+			template: "Move {{.Location}} ({{.Path}}) to an environment variable named {{upper .Field}}.",
+			config: map[string]interface{}{
+				"api_key": []interface{}{"sk-abcdefghijklmnop"},
+			},
+			expected: "Move api_key.0 ($.api_key[0]) to an environment variable named API_KEY.",
+		},
+		{
+			name: "numeric range",
+			rule: Rule{
+				ID:    "TEMP_001",
+				Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0},
+			},
+			template: "Lower {{.Field}} at {{.Location}} to 1.0 or less.",
+			config:   map[string]interface{}{"temperature": 1.5},
+			expected: "Lower temperature at temperature to 1.0 or less.",
+		},
+		{
+			name:     "no template keeps the static recommendation",
+			rule:     secretRule,
+			config:   map[string]interface{}{"api_key": "sk-abcdefghijklmnop"},
+			expected: "Remove the API key.",
+		},
+		{
+			name:     "failed render keeps the static recommendation",
+			rule:     secretRule,
+			template: "Replace {{.Missing}}",
+			config:   map[string]interface{}{"api_key": "sk-abcdefghijklmnop"},
+			expected: "Remove the API key.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.RecommendationTemplate = tt.template
+			finding := firstFinding(rule, &Config{Data: tt.config})
+			if finding == nil {
+				t.Fatal("expected a finding")
+			}
+			if finding.Recommendation != tt.expected {
+				t.Errorf("expected recommendation %q, got %q", tt.expected, finding.Recommendation)
+			}
+		})
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"api_key":              "api_key",
+		"tools.0.api_key":      "api_key",
+		"keys.1":               "keys",
+		"rate_limit, rpm, tpm": "rate_limit, rpm, tpm",
+		"providers.openai.key": "key",
+		"":                     "",
+	}
+	for location, expected := range tests {
+		if got := fieldName(location); got != expected {
+			t.Errorf("fieldName(%q) = %q, want %q", location, got, expected)
+		}
+	}
+}
+
+func TestNewScanner_InvalidRecommendationTemplate(t *testing.T) {
+	for _, template := range []string{"Remove {{.Field", "Remove {{.Missing}}"} {
+		_, err := newScanner(RulesFile{Rules: []Rule{{
+			ID:                     "BAD_001",
+			Check:                  Check{Type: "field_exists", Field: "debug"},
+			RecommendationTemplate: template,
+		}}})
+		if err == nil || !strings.Contains(err.Error(), "BAD_001") {
+			t.Errorf("expected %q to fail naming the rule, got %v", template, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// secretPrefixes start well-known credential formats, which are masked
+// whatever their entropy
+var secretPrefixes = []string{"sk-", "sk_", "pk_", "rk_", "ghp_", "gho_", "ghs_", "github_pat_", "xoxb-", "xoxp-", "AKIA", "ASIA", "AIza", "hf_", "glpat-"}
+
+// secretCandidate finds the runs of characters that credentials and
+// base64 blobs are made of. Dots are excluded so dotted paths split apart.
+var secretCandidate = regexp.MustCompile(`[A-Za-z0-9_+/=-]{12,}`)
+
+// MaskSecret hides all but the first 3 and last 2 characters of value.
+// Values of 8 characters or fewer are masked entirely.
+func MaskSecret(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:3]) + "…" + string(runes[len(runes)-2:])
+}
+
+// LooksLikeSecret reports whether token resembles a credential: a known key
+// prefix, or 16 or more characters mixing letters and digits with high
+// entropy
+func LooksLikeSecret(token string) bool {
+	for _, prefix := range secretPrefixes {
+		if strings.HasPrefix(token, prefix) && len(token) >= len(prefix)+8 {
+			return true
+		}
+	}
+	if len(token) < 16 {
+		return false
+	}
+	hasLetter := strings.IndexFunc(token, unicode.IsLetter) >= 0
+	hasDigit := strings.IndexFunc(token, unicode.IsDigit) >= 0
+	return hasLetter && hasDigit && shannonEntropy(token) >= 3.5
+}
+
+// RedactSecrets masks every secret-looking token in text with MaskSecret
+func RedactSecrets(text string) string {
+	return secretCandidate.ReplaceAllStringFunc(text, func(token string) string {
+		if LooksLikeSecret(token) {
+			return MaskSecret(token)
+		}
+		return token
+	})
+}
+
+// RedactFinding returns finding with its evidence masked and any
+// secret-looking text in its location, path, detail, and recommendation
+// masked, so it can be shown where logs are widely readable
+func RedactFinding(finding Finding) Finding {
+	if finding.Evidence != "" {
+		finding.Evidence = MaskSecret(finding.Evidence)
+	}
+	finding.Location = RedactSecrets(finding.Location)
+	finding.Path = RedactSecrets(finding.Path)
+	finding.Pointer = RedactSecrets(finding.Pointer)
+	finding.Detail = RedactSecrets(finding.Detail)
+	finding.Recommendation = RedactSecrets(finding.Recommendation)
+	return finding
+}
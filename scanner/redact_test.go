@@ -0,0 +1,53 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain location", text: "sampling.temperature", want: "sampling.temperature"},
+		{name: "numeric detail", text: "temperature = 1.9 (max allowed 1)", want: "temperature = 1.9 (max allowed 1)"},
+		{name: "long identifier without digits", text: "services.production_llm_gateway_primary", want: "services.production_llm_gateway_primary"},
+		{name: "known prefix", text: "api_key = sk-abcdefghijklmnop", want: "api_key = sk-…op"},
+		{name: "high entropy token", text: "token a8Fk2Lq9Zx7Rt4Vm1Np6", want: "token a8F…p6"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RedactSecrets(tt.text); got != tt.want {
+				t.Errorf("RedactSecrets(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactFinding(t *testing.T) {
+	secret := "sk-live-4f9Kx2Lq8Zr7Tn3Vb6Wm"
+	finding := RedactFinding(Finding{
+		RuleID:         "SECRETS_001",
+		Location:       "keys." + secret,
+		Evidence:       secret,
+		Detail:         "keys." + secret + " holds a literal value",
+		Recommendation: "Rotate " + secret + " and load it from the environment.",
+	})
+
+	for name, value := range map[string]string{
+		"location":       finding.Location,
+		"evidence":       finding.Evidence,
+		"detail":         finding.Detail,
+		"recommendation": finding.Recommendation,
+	} {
+		if strings.Contains(value, secret) {
+			t.Errorf("%s still holds the full secret: %q", name, value)
+		}
+		if !strings.Contains(value, "sk-…Wm") {
+			t.Errorf("%s = %q, want the sk-…Wm prefix and suffix", name, value)
+		}
+	}
+}
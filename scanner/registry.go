@@ -0,0 +1,92 @@
+package scanner
+
+import "sync"
+
+// CheckFunc evaluates a rule against a config. It reports whether the rule
+// is violated and, if so, the location to show in the finding.
+type CheckFunc func(rule Rule, config *Config) (bool, string)
+
+// checkResult is what the engine records from a check. Built-in checks can
+// fill in more than the location; custom checks are adapted from CheckFunc.
+type checkResult struct {
+	violated bool
+	location string
+	path     string
+	evidence string
+	// detail explains the violation, such as the value and the limit it broke
+	detail string
+	// match is the text a pattern matched, so distinct matches in one value
+	// are reported separately
+	match string
+}
+
+// checkFunc returns one result per violation, or none when the rule passes
+type checkFunc func(rule Rule, config *Config) []checkResult
+
+var (
+	checksMu sync.RWMutex
+	checks   = make(map[string]checkFunc)
+)
+
+func init() {
+	// Secret-style checks report every matching value
+	checks["pattern_match"] = checkPatternMatch
+	checks["entropy"] = checkEntropy
+	checks["requires_interpolation"] = checkRequiresInterpolation
+	checks["secret_scan"] = checkSecretScan
+	checks["url_scheme"] = checkURLScheme
+
+	// Checks that target a single node report its path directly
+	checks["numeric_range"] = single(checkNumericRange)
+	checks["field_exists"] = single(checkFieldExists)
+	checks["deprecated_field"] = single(checkFieldExists)
+	checks["field_type"] = single(checkFieldType)
+	checks["count"] = single(checkCount)
+	checks["key_pattern"] = single(checkKeyPattern)
+	checks["context_window_exceeded"] = single(checkContextWindowExceeded)
+	checks["mutually_exclusive"] = single(checkMutuallyExclusive)
+
+	builtins := map[string]CheckFunc{
+		"missing_field":            checkMissingField,
+		"missing_fields":           checkMissingFields,
+		"combined_conditions":      checkCombinedConditions,
+		"conditional_missing":      checkConditionalMissing,
+		"field_check":              checkFieldCheck,
+		"stop_sequence_complexity": checkStopSequenceComplexity,
+	}
+	for name, fn := range builtins {
+		checks[name] = adaptCheck(fn)
+	}
+}
+
+// RegisterCheck makes a check type available to rules under name, replacing
+// any existing check with that name. Register custom checks before scanning.
+func RegisterCheck(name string, fn CheckFunc) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks[name] = adaptCheck(fn)
+}
+
+func lookupCheck(name string) (checkFunc, bool) {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+	fn, ok := checks[name]
+	return fn, ok
+}
+
+// single adapts a check that reports at most one violation
+func single(fn func(rule Rule, config *Config) checkResult) checkFunc {
+	return func(rule Rule, config *Config) []checkResult {
+		if result := fn(rule, config); result.violated {
+			return []checkResult{result}
+		}
+		return nil
+	}
+}
+
+func adaptCheck(fn CheckFunc) checkFunc {
+	return single(func(rule Rule, config *Config) checkResult {
+		violated, location := fn(rule, config)
+		return checkResult{violated: violated, location: location}
+	})
+}
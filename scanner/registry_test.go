@@ -0,0 +1,44 @@
+package scanner
+
+import "testing"
+
+func TestRegisterCheck(t *testing.T) {
+	RegisterCheck("test_model_allowlist", func(rule Rule, config *Config) (bool, string) {
+		value, _ := config.GetValue("model")
+		model, _ := value.(string)
+		for _, allowed := range rule.Check.Values {
+			if model == allowed {
+				return false, ""
+			}
+		}
+		return true, "model"
+	})
+
+	rule := Rule{
+		ID:       "CUSTOM_001",
+		Severity: "MEDIUM",
+		Check: Check{
+			Type:   "test_model_allowlist",
+			Values: []interface{}{"gpt-4o"},
+		},
+	}
+
+	finding := firstFinding(rule, &Config{Data: map[string]interface{}{"model": "gpt-3.5-turbo"}})
+	if finding == nil {
+		t.Fatal("expected the custom check to fire")
+	}
+	if finding.Location != "model" {
+		t.Errorf("Location = %q, want model", finding.Location)
+	}
+
+	if firstFinding(rule, &Config{Data: map[string]interface{}{"model": "gpt-4o"}}) != nil {
+		t.Error("expected no finding for an allowed model")
+	}
+}
+
+func TestCheckRule_UnknownType(t *testing.T) {
+	rule := Rule{ID: "UNKNOWN_001", Check: Check{Type: "no_such_check"}}
+	if firstFinding(rule, &Config{Data: map[string]interface{}{}}) != nil {
+		t.Error("expected no finding for an unregistered check type")
+	}
+}
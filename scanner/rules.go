@@ -3,109 +3,453 @@ package scanner
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 )
 
-// CheckRule evaluates a rule against the config
-func CheckRule(rule Rule, config *Config) *Finding {
-	var violated bool
-	var location string
-
-	switch rule.Check.Type {
-	case "pattern_match":
-		violated, location = checkPatternMatch(rule, config)
-	case "numeric_range":
-		violated, location = checkNumericRange(rule, config)
-	case "missing_field":
-		violated, location = checkMissingField(rule, config)
-	case "missing_fields":
-		violated, location = checkMissingFields(rule, config)
-	case "field_exists":
-		violated, location = checkFieldExists(rule, config)
-	case "combined_conditions":
-		violated, location = checkCombinedConditions(rule, config)
-	case "conditional_missing":
-		violated, location = checkConditionalMissing(rule, config)
-	case "field_check":
-		violated, location = checkFieldCheck(rule, config)
-	case "stop_sequence_complexity":
-		violated, location = checkStopSequenceComplexity(rule, config)
-	default:
+// CheckRule evaluates a rule against the config using the check registered
+// for its type and returns a finding for each violation. pattern_match,
+// entropy, and requires_interpolation checks report every matching value;
+// other checks report at most one finding. Violations at the same node are
+// reported once. MaxFindings is not applied here but by the Scanner, per
+// file. Rules with an unknown type, or whose applies_when conditions do not
+// hold, never fire. Rules with several checks report at most one finding
+// (see Rule.Checks).
+func CheckRule(rule Rule, config *Config) []Finding {
+	if !rule.AppliesTo(config) {
 		return nil
 	}
 
-	if !violated {
+	var results []checkResult
+	if len(rule.Checks) > 0 {
+		results = combinedResults(rule, config)
+	} else {
+		results = runCheck(rule, config)
+	}
+
+	var findings []Finding
+	seen := make(map[string]bool)
+	for _, result := range results {
+		key := result.path
+		if key == "" {
+			key = result.location + "\x00" + result.evidence
+		}
+		if result.match != "" {
+			key += "\x00" + result.match
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		findings = append(findings, newFinding(rule, result))
+	}
+	return findings
+}
+
+// runCheck evaluates the rule's Check, returning nil for an unknown type
+func runCheck(rule Rule, config *Config) []checkResult {
+	check, ok := lookupCheck(rule.Check.Type)
+	if !ok {
+		return nil
+	}
+	config = ruleConfig(rule, config)
+	results := check(rule, config)
+	if rule.Check.Negate {
+		results = negateResults(rule, config, results)
+	}
+	return results
+}
+
+// combinedResults evaluates each of the rule's Checks and combines them by
+// its Logic into at most one result, which lists the location of every check
+// that fired
+func combinedResults(rule Rule, config *Config) []checkResult {
+	or := strings.EqualFold(rule.Logic, "or")
+	var fired []checkResult
+	for _, check := range rule.Checks {
+		sub := rule
+		sub.Check = check
+		results := runCheck(sub, config)
+		if len(results) == 0 {
+			if !or {
+				return nil
+			}
+			continue
+		}
+		fired = append(fired, results[0])
+	}
+	if len(fired) == 0 {
 		return nil
 	}
+	if len(fired) == 1 {
+		return fired
+	}
+
+	combined := checkResult{violated: true}
+	var locations, details []string
+	for _, result := range fired {
+		if result.location != "" {
+			locations = append(locations, result.location)
+		}
+		if result.detail != "" {
+			details = append(details, result.detail)
+		}
+		if combined.evidence == "" {
+			combined.evidence = result.evidence
+		}
+	}
+	combined.location = strings.Join(locations, " + ")
+	combined.detail = strings.Join(details, "; ")
+	return []checkResult{combined}
+}
+
+// validateChecks rejects rules that set both check and checks, or a logic
+// other than "and" or "or"
+func validateChecks(rules RulesFile) error {
+	for _, rule := range rules.Rules {
+		if len(rule.Checks) == 0 {
+			if rule.Logic != "" {
+				return fmt.Errorf("rule %s sets logic without checks", rule.ID)
+			}
+			continue
+		}
+		if rule.Check.Type != "" {
+			return fmt.Errorf("rule %s sets both check and checks", rule.ID)
+		}
+		if rule.Logic != "" && !strings.EqualFold(rule.Logic, "and") && !strings.EqualFold(rule.Logic, "or") {
+			return fmt.Errorf("rule %s has invalid logic %q (use and or or)", rule.ID, rule.Logic)
+		}
+	}
+	return nil
+}
+
+// ruleConfig returns config as the rule's check should see it, folding field
+// names when the check is case-insensitive
+func ruleConfig(rule Rule, config *Config) *Config {
+	if rule.Check.CaseInsensitive && !config.CaseInsensitive {
+		folded := *config
+		folded.CaseInsensitive = true
+		return &folded
+	}
+	return config
+}
 
-	return &Finding{
+// newFinding builds the finding reported for one violation of rule
+func newFinding(rule Rule, result checkResult) Finding {
+	finding := Finding{
 		RuleID:         rule.ID,
 		Name:           rule.Name,
 		Severity:       rule.Severity,
 		Category:       rule.Category,
+		CWE:            rule.CWE,
 		Description:    rule.Description,
-		Location:       location,
-		Recommendation: rule.Recommendation,
+		Location:       result.location,
+		Path:           result.path,
+		Pointer:        JSONPointer(result.path),
+		Evidence:       result.evidence,
+		Detail:         result.detail,
 		References:     rule.References,
+		RuleSetVersion: rule.RuleSetVersion,
 	}
+
+	recommendation := renderRecommendation(rule, finding)
+	if rule.Check.Type == "deprecated_field" && rule.Check.ReplacedBy != "" {
+		replacement := fmt.Sprintf("Use %s instead.", rule.Check.ReplacedBy)
+		if recommendation == "" {
+			recommendation = replacement
+		} else {
+			recommendation = strings.TrimSpace(recommendation) + " " + replacement
+		}
+	}
+	finding.Recommendation = recommendation
+	return finding
 }
 
-func checkPatternMatch(rule Rule, config *Config) (bool, string) {
-	// Check specific fields if provided
-	if len(rule.Fields) > 0 {
-		for _, field := range rule.Fields {
-			values := config.GetAllFieldValues(field)
-			for _, val := range values {
-				if str, ok := val.(string); ok {
-					for _, pattern := range rule.Check.Patterns {
-						if matched, _ := regexp.MatchString(pattern, str); matched {
-							return true, field
-						}
-					}
+// negateResults inverts a check's decision. A negated check that would have
+// passed reports the field it targets, since there is no matching value, but
+// only when that field is present: a config without the field has no value
+// to fail the check. Checks that target no field are always inverted, as
+// are checks about presence itself (field_exists, deprecated_field), whose
+// negation is that the field is missing.
+func negateResults(rule Rule, config *Config, results []checkResult) []checkResult {
+	if len(results) > 0 {
+		return nil
+	}
+
+	check := rule.Check
+	aboutPresence := check.Type == "field_exists" || check.Type == "deprecated_field"
+	if check.Path != "" {
+		if !aboutPresence && len(pathValues(check.Path, config)) == 0 {
+			return nil
+		}
+		return []checkResult{{violated: true, location: check.Path}}
+	}
+
+	var targets []string
+	switch {
+	case check.Parameter != "":
+		targets = []string{check.Parameter}
+	case len(check.Parameters) > 0:
+		targets = check.Parameters
+	case len(check.fieldNames()) > 0:
+		targets = check.fieldNames()
+	default:
+		targets = rule.Fields
+	}
+	if len(targets) == 0 {
+		return []checkResult{{violated: true}}
+	}
+	if aboutPresence {
+		return []checkResult{{violated: true, location: strings.Join(targets, ", ")}}
+	}
+	for _, target := range targets {
+		if len(config.findFieldValues(target)) > 0 {
+			return []checkResult{{violated: true, location: strings.Join(targets, ", ")}}
+		}
+	}
+	return nil
+}
+
+func checkPatternMatch(rule Rule, config *Config) []checkResult {
+	patterns := compilePatterns(rule.Check)
+	allowlist := compileAllowlist(rule.Check)
+	var results []checkResult
+	report := func(value interface{}, location, path string) {
+		for _, match := range matchPatterns(value, patterns, allowlist) {
+			results = append(results, checkResult{violated: true, location: location, path: path, evidence: evidenceFor(rule.Check, match), match: match})
+		}
+	}
+
+	// Check the exact path if provided
+	if rule.Check.Path != "" {
+		for _, found := range pathValues(rule.Check.Path, config) {
+			report(found.value, rule.Check.Path, found.path)
+		}
+		return results
+	}
+
+	// Check specific fields if provided, reporting every match in every
+	// value; each string in an array field is matched on its own and
+	// reported with its index. The check's own fields take precedence over
+	// the rule's.
+	fields := rule.Check.fieldNames()
+	if len(fields) == 0 {
+		fields = rule.Fields
+	}
+	if len(fields) > 0 {
+		for _, field := range fields {
+			for _, found := range config.findFieldValues(field) {
+				items, isArray := found.value.([]interface{})
+				if !isArray {
+					report(found.value, field, found.path)
+					continue
+				}
+				for i, item := range items {
+					report(item, fmt.Sprintf("%s.%d", field, i), jsonPathIndex(found.path, i))
 				}
 			}
 		}
-		return false, ""
+		return results
 	}
 
 	// Check all content
-	content := config.GetAllContent()
-	for _, pattern := range rule.Check.Patterns {
-		if matched, _ := regexp.MatchString(pattern, content); matched {
-			return true, "config content"
+	report(config.GetAllContent(), "config content", "")
+	return results
+}
+
+// maxPatternInput bounds how much of a single value is matched against
+// rule patterns. Go regexps run in linear time, but a huge prompt multiplied
+// by every pattern still adds up.
+const maxPatternInput = 1 << 20
+
+// matchPatterns returns every distinct substring of a string value matching
+// any of the patterns, in pattern order and then by position. Matches that an
+// allowlist entry also matches are skipped, as are repeats and matches
+// overlapping one already found, so two patterns for the same key report it
+// once.
+func matchPatterns(value interface{}, patterns, allowlist []*regexp.Regexp) []string {
+	str, ok := value.(string)
+	if !ok {
+		return nil
+	}
+	if len(str) > maxPatternInput {
+		str = str[:maxPatternInput]
+	}
+	var matches []string
+	var claimed [][]int
+	seen := make(map[string]bool)
+	for _, re := range patterns {
+		for _, loc := range re.FindAllStringIndex(str, -1) {
+			match := str[loc[0]:loc[1]]
+			if seen[match] || overlaps(loc, claimed) || matchesAny(match, allowlist) {
+				continue
+			}
+			seen[match] = true
+			claimed = append(claimed, loc)
+			matches = append(matches, match)
 		}
 	}
+	return matches
+}
 
-	return false, ""
+func matchesAny(value string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// patternFlags maps check flags to inline regexp flags
+var patternFlags = map[string]string{
+	"ignorecase": "i",
+	"multiline":  "m",
+	"dotall":     "s",
+}
+
+// patternCache holds compiled patterns keyed by their final expression;
+// invalid expressions are cached as nil
+var patternCache sync.Map
+
+// compilePatterns compiles the check's patterns with its flags and anchoring
+// applied. Patterns that fail to compile are skipped.
+func compilePatterns(check Check) []*regexp.Regexp {
+	return compileExpressions(check.Patterns, check.Flags, check.Anchored)
+}
+
+// compileAllowlist compiles the check's allowlist with its flags. Entries
+// are never anchored; they only need to match within a matched substring.
+func compileAllowlist(check Check) []*regexp.Regexp {
+	return compileExpressions(check.Allowlist, check.Flags, false)
+}
+
+func compileExpressions(patterns, flags []string, anchored bool) []*regexp.Regexp {
+	prefix := ""
+	for _, flag := range flags {
+		prefix += patternFlags[strings.ToLower(flag)]
+	}
+	if prefix != "" {
+		prefix = "(?" + prefix + ")"
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr := pattern
+		if anchored {
+			expr = "^(?:" + expr + ")$"
+		}
+		expr = prefix + expr
+
+		if cached, ok := patternCache.Load(expr); ok {
+			if re := cached.(*regexp.Regexp); re != nil {
+				compiled = append(compiled, re)
+			}
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			re = nil
+		}
+		patternCache.Store(expr, re)
+		if re != nil {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// evidenceFor returns the matched text for a finding, redacted unless the
+// check explicitly sets redact: false
+func evidenceFor(check Check, match string) string {
+	if check.Redact != nil && !*check.Redact {
+		return match
+	}
+	return RedactValue(match)
+}
+
+// RedactValue masks a secret-looking value, keeping a short prefix and
+// suffix so it can still be recognized (sk-proj-abc…901 -> sk-proj…901)
+func RedactValue(value string) string {
+	runes := []rune(value)
+	switch {
+	case len(runes) == 0:
+		return ""
+	case len(runes) <= 6:
+		return strings.Repeat("*", len(runes))
+	case len(runes) <= 12:
+		return string(runes[:3]) + "…"
+	default:
+		return string(runes[:7]) + "…" + string(runes[len(runes)-3:])
+	}
 }
 
-func checkNumericRange(rule Rule, config *Config) (bool, string) {
+// pathValues returns the values at a dotted path, which may use "*"
+// wildcards (see Config.GetValuesByPath), or nil if the path does not resolve
+func pathValues(path string, config *Config) []fieldValue {
+	return config.lookupPaths(path)
+}
+
+func checkNumericRange(rule Rule, config *Config) checkResult {
+	// Check the exact path if provided
+	if rule.Check.Path != "" {
+		return checkNumericValues(pathValues(rule.Check.Path, config), rule.Check.Path, rule.Check)
+	}
+
 	// Check single parameter
 	if rule.Check.Parameter != "" {
 		return checkSingleNumeric(rule.Check.Parameter, rule.Check, config)
 	}
 
-	// Check multiple parameters
-	if len(rule.Check.Parameters) > 0 {
-		for _, param := range rule.Check.Parameters {
-			if violated, loc := checkSingleNumeric(param, rule.Check, config); violated {
-				return true, loc
-			}
+	// Check multiple parameters, or the check's fields when none are given
+	params := rule.Check.Parameters
+	if len(params) == 0 {
+		params = rule.Check.fieldNames()
+	}
+	for _, param := range params {
+		if result := checkSingleNumeric(param, rule.Check, config); result.violated {
+			return result
 		}
 	}
 
-	return false, ""
+	return checkResult{}
 }
 
-func checkSingleNumeric(param string, check Check, config *Config) (bool, string) {
-	values := config.GetAllFieldValues(param)
-	if len(values) == 0 {
-		return false, ""
+// numericDetail describes an out-of-range value and the bound it crossed
+func numericDetail(location string, value interface{}, num float64, check Check) string {
+	if num < check.Min {
+		return fmt.Sprintf("%s = %v (min allowed %s)", location, value, formatNumber(check.Min))
 	}
+	return fmt.Sprintf("%s = %v (max allowed %s)", location, value, formatNumber(check.Max))
+}
+
+// formatNumber prints a threshold without trailing zeros
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+func checkSingleNumeric(param string, check Check, config *Config) checkResult {
+	return checkNumericValues(config.findFieldValues(param), param, check)
+}
+
+// checkNumericValues reports whether the numeric values at location fall
+// outside [check.Min, check.Max]. With condition "all" it fires only when
+// every numeric value is out of range; otherwise ("any", "any_value_exceeds",
+// or unset) a single out-of-range value is enough. The path is set when a
+// single node is responsible.
+func checkNumericValues(values []fieldValue, location string, check Check) checkResult {
+	if check.Min == 0 && check.Max == 0 {
+		return checkResult{}
+	}
+
+	requireAll := check.Condition == "all"
+	var outOfRange []string
+	var outOfRangeValues []string
 
 	for _, val := range values {
 		var num float64
-		switch v := val.(type) {
+		switch v := val.value.(type) {
 		case float64:
 			num = v
 		case float32:
@@ -118,48 +462,89 @@ func checkSingleNumeric(param string, check Check, config *Config) (bool, string
 			continue
 		}
 
-		// Check if outside range
-		if check.Min != 0 || check.Max != 0 {
-			if num < check.Min || num > check.Max {
-				return true, param
+		if num >= check.Min && num <= check.Max {
+			if requireAll {
+				return checkResult{}
 			}
+			continue
 		}
-
-		// Check specific conditions for any_value_exceeds
-		if check.Condition == "any_value_exceeds" {
-			if num < check.Min || num > check.Max {
-				return true, param
-			}
+		if !requireAll {
+			return checkResult{violated: true, location: location, path: val.path, detail: numericDetail(location, val.value, num, check)}
 		}
+		outOfRange = append(outOfRange, val.path)
+		outOfRangeValues = append(outOfRangeValues, fmt.Sprintf("%v", val.value))
 	}
 
-	return false, ""
+	if requireAll && len(outOfRange) > 0 {
+		result := checkResult{
+			violated: true,
+			location: location,
+			detail:   fmt.Sprintf("%s = %s (allowed %s to %s)", location, strings.Join(outOfRangeValues, ", "), formatNumber(check.Min), formatNumber(check.Max)),
+		}
+		if len(outOfRange) == 1 {
+			result.path = outOfRange[0]
+		}
+		return result
+	}
+	return checkResult{}
 }
 
+// checkMissingField flags a config missing any of the check's fields,
+// listing every missing one
 func checkMissingField(rule Rule, config *Config) (bool, string) {
-	field := rule.Check.Field
-	if !config.HasField(field) {
-		return true, field
+	var missing []string
+	for _, field := range rule.Check.fieldNames() {
+		if !config.HasField(field) {
+			missing = append(missing, field)
+		}
 	}
-	return false, ""
+	if len(missing) == 0 {
+		return false, ""
+	}
+	return true, strings.Join(missing, ", ")
 }
 
 func checkMissingFields(rule Rule, config *Config) (bool, string) {
-	for _, field := range rule.Check.Fields {
+	fields := rule.Check.fieldNames()
+	for _, field := range fields {
 		if config.HasField(field) {
 			return false, ""
 		}
 	}
 	// All fields are missing
-	return true, strings.Join(rule.Check.Fields, ", ")
+	return true, strings.Join(fields, ", ")
 }
 
-func checkFieldExists(rule Rule, config *Config) (bool, string) {
-	field := rule.Check.Field
-	if config.HasField(field) {
-		return true, field
+// checkMutuallyExclusive flags configs that set two or more of the check's
+// fields, listing every field present in the location
+func checkMutuallyExclusive(rule Rule, config *Config) checkResult {
+	var present []string
+	for _, field := range rule.Check.fieldNames() {
+		if config.HasField(field) {
+			present = append(present, field)
+		}
 	}
-	return false, ""
+	if len(present) < 2 {
+		return checkResult{}
+	}
+	location := strings.Join(present, ", ")
+	return checkResult{violated: true, location: location, detail: fmt.Sprintf("%s are set together", location)}
+}
+
+func checkFieldExists(rule Rule, config *Config) checkResult {
+	if rule.Check.Path != "" {
+		if found := pathValues(rule.Check.Path, config); len(found) > 0 {
+			return checkResult{violated: true, location: rule.Check.Path, path: found[0].path}
+		}
+		return checkResult{}
+	}
+
+	for _, field := range rule.Check.fieldNames() {
+		if found := config.findFieldValues(field); len(found) > 0 {
+			return checkResult{violated: true, location: field, path: found[0].path}
+		}
+	}
+	return checkResult{}
 }
 
 func checkCombinedConditions(rule Rule, config *Config) (bool, string) {
@@ -183,6 +568,10 @@ func checkCombinedConditions(rule Rule, config *Config) (bool, string) {
 		if metCount >= 2 {
 			return true, strings.Join(locations, ", ")
 		}
+	case "at_least_n":
+		if rule.Check.RequireCount > 0 && metCount >= rule.Check.RequireCount {
+			return true, strings.Join(locations, ", ")
+		}
 	case "both":
 		if metCount == 2 {
 			return true, strings.Join(locations, ", ")
@@ -191,12 +580,32 @@ func checkCombinedConditions(rule Rule, config *Config) (bool, string) {
 		if metCount > 0 {
 			return true, strings.Join(locations, ", ")
 		}
+	case "exactly_one":
+		if metCount == 1 {
+			return true, strings.Join(locations, ", ")
+		}
+	case "none":
+		// Nothing matched, so report every parameter that was checked
+		if metCount == 0 && len(rule.Check.Conditions) > 0 {
+			params := make([]string, 0, len(rule.Check.Conditions))
+			for _, condition := range rule.Check.Conditions {
+				params = append(params, condition.Parameter)
+			}
+			return true, strings.Join(params, ", ")
+		}
 	}
 
 	return false, ""
 }
 
 func checkCondition(condition Condition, config *Config) bool {
+	switch condition.Operator {
+	case "exists":
+		return config.HasField(condition.Parameter)
+	case "not_exists":
+		return !config.HasField(condition.Parameter)
+	}
+
 	values := config.GetAllFieldValues(condition.Parameter)
 	if len(values) == 0 {
 		return false
@@ -205,12 +614,20 @@ func checkCondition(condition Condition, config *Config) bool {
 	for _, val := range values {
 		switch condition.Operator {
 		case "greater_than":
-			if num, ok := toFloat(val); ok {
-				if threshold, ok := toFloat(condition.Value); ok {
-					if num > threshold {
-						return true
-					}
-				}
+			if compareNumbers(val, condition.Value, func(a, b float64) bool { return a > b }) {
+				return true
+			}
+		case "greater_than_or_equal":
+			if compareNumbers(val, condition.Value, func(a, b float64) bool { return a >= b }) {
+				return true
+			}
+		case "less_than":
+			if compareNumbers(val, condition.Value, func(a, b float64) bool { return a < b }) {
+				return true
+			}
+		case "less_than_or_equal":
+			if compareNumbers(val, condition.Value, func(a, b float64) bool { return a <= b }) {
+				return true
 			}
 		case "not_equals":
 			if fmt.Sprintf("%v", val) != fmt.Sprintf("%v", condition.Value) {
@@ -220,12 +637,45 @@ func checkCondition(condition Condition, config *Config) bool {
 			if fmt.Sprintf("%v", val) == fmt.Sprintf("%v", condition.Value) {
 				return true
 			}
+		case "contains":
+			if containsValue(val, condition.Value) {
+				return true
+			}
 		}
 	}
 
 	return false
 }
 
+// compareNumbers applies cmp to val and threshold when both are numeric
+func compareNumbers(val, threshold interface{}, cmp func(a, b float64) bool) bool {
+	num, ok := toFloat(val)
+	if !ok {
+		return false
+	}
+	limit, ok := toFloat(threshold)
+	if !ok {
+		return false
+	}
+	return cmp(num, limit)
+}
+
+// containsValue reports whether a string contains want as a substring, or
+// an array has an element equal to want
+func containsValue(val, want interface{}) bool {
+	switch v := val.(type) {
+	case string:
+		return strings.Contains(v, fmt.Sprintf("%v", want))
+	case []interface{}:
+		for _, item := range v {
+			if fmt.Sprintf("%v", item) == fmt.Sprintf("%v", want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func checkConditionalMissing(rule Rule, config *Config) (bool, string) {
 	// Check if any of HasAny fields exist
 	hasAny := false
@@ -250,8 +700,16 @@ func checkConditionalMissing(rule Rule, config *Config) (bool, string) {
 	return true, strings.Join(rule.Check.MissingAll, ", ")
 }
 
+// checkFieldCheck flags the first field holding one of the check's values.
+// When missing_all is set the check only applies to configs that set none of
+// those fields.
 func checkFieldCheck(rule Rule, config *Config) (bool, string) {
-	for _, field := range rule.Check.Fields {
+	for _, field := range rule.Check.MissingAll {
+		if config.HasField(field) {
+			return false, ""
+		}
+	}
+	for _, field := range rule.Check.fieldNames() {
 		values := config.GetAllFieldValues(field)
 		for _, val := range values {
 			valStr := fmt.Sprintf("%v", val)
@@ -265,37 +723,182 @@ func checkFieldCheck(rule Rule, config *Config) (bool, string) {
 	return false, ""
 }
 
+// checkStopSequenceComplexity flags a stop field with more than
+// max_sequences sequences or one longer than max_length. The sequences are
+// the field's strings at any depth of nested arrays, including the value of
+// objects like {value: "###"}; numbers, booleans, and objects without a
+// value are skipped rather than flagged.
 func checkStopSequenceComplexity(rule Rule, config *Config) (bool, string) {
-	field := rule.Check.Field
-	values := config.GetAllFieldValues(field)
+	for _, field := range rule.Check.fieldNames() {
+		for _, val := range config.GetAllFieldValues(field) {
+			var sequences []string
+			collectSequences(val, &sequences)
 
-	for _, val := range values {
-		switch v := val.(type) {
-		case []interface{}:
-			// Check number of sequences
-			if rule.Check.MaxSequences > 0 && len(v) > rule.Check.MaxSequences {
+			if rule.Check.MaxSequences > 0 && len(sequences) > rule.Check.MaxSequences {
 				return true, field
 			}
-			// Check length of each sequence
 			if rule.Check.MaxLength > 0 {
-				for _, item := range v {
-					if str, ok := item.(string); ok {
-						if len(str) > rule.Check.MaxLength {
-							return true, field
-						}
+				for _, sequence := range sequences {
+					if len(sequence) > rule.Check.MaxLength {
+						return true, field
 					}
 				}
 			}
-		case string:
-			if rule.Check.MaxLength > 0 && len(v) > rule.Check.MaxLength {
-				return true, field
-			}
 		}
 	}
 
 	return false, ""
 }
 
+// collectSequences gathers the stop sequences in val: strings, the elements
+// of nested arrays, and the value field of objects
+func collectSequences(val interface{}, sequences *[]string) {
+	switch v := val.(type) {
+	case string:
+		*sequences = append(*sequences, v)
+	case []interface{}:
+		for _, item := range v {
+			collectSequences(item, sequences)
+		}
+	case map[string]interface{}:
+		if value, ok := v["value"]; ok {
+			collectSequences(value, sequences)
+		}
+	}
+}
+
+// flattenArray returns the elements of v with nested arrays expanded in
+// place, at any depth
+func flattenArray(v []interface{}) []interface{} {
+	var flat []interface{}
+	for _, item := range v {
+		if nested, ok := item.([]interface{}); ok {
+			flat = append(flat, flattenArray(nested)...)
+			continue
+		}
+		flat = append(flat, item)
+	}
+	return flat
+}
+
+func checkFieldType(rule Rule, config *Config) checkResult {
+	for _, field := range rule.Check.fieldNames() {
+		for _, found := range config.findFieldValues(field) {
+			if actual := valueType(found.value); actual != rule.Check.ExpectedType {
+				detail := fmt.Sprintf("%s is %s, expected %s", field, actual, rule.Check.ExpectedType)
+				return checkResult{violated: true, location: field, path: found.path, detail: detail}
+			}
+		}
+	}
+	return checkResult{}
+}
+
+// checkCount flags arrays (by length) or objects (by number of keys) whose
+// size falls outside min_count/max_count. Nested arrays are flattened, so
+// [[a, b], [c]] has 3 entries; any other element, such as an object, counts
+// once. A zero bound is not enforced.
+func checkCount(rule Rule, config *Config) checkResult {
+	for _, field := range rule.Check.fieldNames() {
+		for _, found := range config.findFieldValues(field) {
+			var count int
+			switch v := found.value.(type) {
+			case []interface{}:
+				count = len(flattenArray(v))
+			case map[string]interface{}:
+				count = len(v)
+			default:
+				continue
+			}
+
+			if (rule.Check.MinCount > 0 && count < rule.Check.MinCount) ||
+				(rule.Check.MaxCount > 0 && count > rule.Check.MaxCount) {
+				detail := fmt.Sprintf("%s has %d entries (%s)", field, count, countBounds(rule.Check))
+				return checkResult{violated: true, location: field, path: found.path, detail: detail}
+			}
+		}
+	}
+	return checkResult{}
+}
+
+// countBounds describes the entry counts a count check allows
+func countBounds(check Check) string {
+	switch {
+	case check.MinCount > 0 && check.MaxCount > 0:
+		return fmt.Sprintf("allowed %d to %d", check.MinCount, check.MaxCount)
+	case check.MinCount > 0:
+		return fmt.Sprintf("min allowed %d", check.MinCount)
+	default:
+		return fmt.Sprintf("max allowed %d", check.MaxCount)
+	}
+}
+
+// checkKeyPattern flags the first key, at any depth, whose name matches one
+// of the check's patterns. Keys listed in allow, either by name or by dotted
+// path, are skipped. The location is the dotted path of the key.
+func checkKeyPattern(rule Rule, config *Config) checkResult {
+	patterns := compilePatterns(rule.Check)
+	allowed := func(key, path string) bool {
+		for _, allow := range rule.Check.Allow {
+			if strings.EqualFold(allow, key) || strings.EqualFold(allow, path) {
+				return true
+			}
+		}
+		return false
+	}
+	return findKey(config.Data, "", "$", patterns, allowed)
+}
+
+func findKey(val interface{}, prefix, jsonPath string, patterns []*regexp.Regexp, allowed func(key, path string) bool) checkResult {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			path := key
+			if prefix != "" {
+				path = prefix + "." + key
+			}
+			keyPath := jsonPathKey(jsonPath, key)
+			if !allowed(key, path) {
+				for _, re := range patterns {
+					if re.MatchString(key) {
+						return checkResult{violated: true, location: path, path: keyPath}
+					}
+				}
+			}
+			if result := findKey(v[key], path, keyPath, patterns, allowed); result.violated {
+				return result
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if result := findKey(item, fmt.Sprintf("%s.%d", prefix, i), jsonPathIndex(jsonPath, i), patterns, allowed); result.violated {
+				return result
+			}
+		}
+	}
+	return checkResult{}
+}
+
+// valueType names the type of a parsed config value: string, number,
+// boolean, array, object, or null
+func valueType(val interface{}) string {
+	switch val.(type) {
+	case string:
+		return "string"
+	case float64, float32, int, int64, int32, uint64, uint32, uint:
+		return "number"
+	case bool:
+		return "boolean"
+	case []interface{}, []map[string]interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case nil:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
+
 func toFloat(val interface{}) (float64, bool) {
 	switch v := val.(type) {
 	case float64:
@@ -310,3 +913,101 @@ func toFloat(val interface{}) (float64, bool) {
 		return 0, false
 	}
 }
+
+// ApplyFixes clamps out-of-range numeric_range values in config to the rule's
+// min or max, modifying config.Data in place. It returns the changes made.
+// Disabled and negated rules, and rules whose applies_when does not hold for
+// config, are skipped.
+func ApplyFixes(rules []Rule, config *Config) []Fix {
+	fixes := []Fix{}
+
+	for _, rule := range rules {
+		check := rule.Check
+		if check.Type != "numeric_range" || (check.Min == 0 && check.Max == 0) || check.Negate {
+			continue
+		}
+		if !rule.IsEnabled() || !rule.AppliesTo(config) {
+			continue
+		}
+
+		if check.Path != "" {
+			parts := strings.Split(check.Path, ".")
+			parentPath := strings.Join(parts[:len(parts)-1], ".")
+			parent := config.Data
+			if parentPath != "" {
+				val, ok := config.GetValue(parentPath)
+				if !ok {
+					continue
+				}
+				if parent, ok = val.(map[string]interface{}); !ok {
+					continue
+				}
+			}
+			key := parts[len(parts)-1]
+			if fixed, ok := clampValue(parent[key], check); ok {
+				fixes = append(fixes, Fix{RuleID: rule.ID, Location: check.Path, Old: parent[key], New: fixed})
+				parent[key] = fixed
+			}
+			continue
+		}
+
+		params := check.Parameters
+		if check.Parameter != "" {
+			params = []string{check.Parameter}
+		}
+		for _, param := range params {
+			clampFieldValues(config.Data, param, "", rule, &fixes)
+		}
+	}
+
+	return fixes
+}
+
+func clampFieldValues(data map[string]interface{}, field, prefix string, rule Rule, fixes *[]Fix) {
+	for _, key := range sortedKeys(data) {
+		val := data[key]
+		location := key
+		if prefix != "" {
+			location = prefix + "." + key
+		}
+		if key == field {
+			if fixed, ok := clampValue(val, rule.Check); ok {
+				*fixes = append(*fixes, Fix{RuleID: rule.ID, Location: location, Old: val, New: fixed})
+				data[key] = fixed
+			}
+		}
+		if nested, ok := val.(map[string]interface{}); ok {
+			clampFieldValues(nested, field, location, rule, fixes)
+		}
+	}
+}
+
+// clampValue returns val clamped to [check.Min, check.Max], keeping integer
+// values integral. It reports false if val is not numeric or already in range.
+func clampValue(val interface{}, check Check) (interface{}, bool) {
+	num, ok := toFloat(val)
+	if !ok {
+		return nil, false
+	}
+
+	var bound float64
+	switch {
+	case num > check.Max:
+		bound = check.Max
+	case num < check.Min:
+		bound = check.Min
+	default:
+		return nil, false
+	}
+
+	switch val.(type) {
+	case int:
+		return int(bound), true
+	case int64:
+		return int64(bound), true
+	case float32:
+		return float32(bound), true
+	default:
+		return bound, true
+	}
+}
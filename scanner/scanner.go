@@ -1,27 +1,113 @@
 package scanner
 
 import (
+	_ "embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// DuplicateKeyRuleID identifies the finding reported for each duplicate key
+// found when parsing JSON in strict mode
+const DuplicateKeyRuleID = "JSON_DUPLICATE_KEY"
+
+// ParseErrorRuleID identifies the finding reported for a file that could
+// not be parsed when Scanner.ParseErrorsAsFindings is set
+const ParseErrorRuleID = "PARSE_ERROR"
+
+// CommentedSecretRuleID identifies the finding reported for each provider
+// key found outside parsed values when Scanner.ScanComments is set
+const CommentedSecretRuleID = "COMMENTED_SECRET"
+
+// TruncatedRuleID identifies the finding that stands in for the findings a
+// rule's max_findings dropped from a file
+const TruncatedRuleID = "FINDINGS_TRUNCATED"
+
 // Scanner holds the rules and performs scans
 type Scanner struct {
 	rules RulesFile
+
+	// ParseOptions is applied to every file read by ScanFile
+	ParseOptions ParseOptions
+
+	// Tags restricts scanning to rules carrying at least one of these tags.
+	// An empty list runs every enabled rule.
+	Tags []string
+
+	// RecordPassed makes ScanFile list the IDs of rules that were evaluated
+	// and did not fire in ScanResult.Passed
+	RecordPassed bool
+
+	// CollectStats times every rule evaluation and file scan; read the
+	// totals with Stats. Off by default to avoid the overhead.
+	CollectStats bool
+
+	// FailFast stops each scan at the first finding, so results hold at
+	// most one finding
+	FailFast bool
+
+	// RiskWeights overrides DefaultRiskWeights when computing
+	// ScanResult.RiskScore
+	RiskWeights map[string]int
+
+	// ParseErrorsAsFindings makes ScanFile report a file that exists but
+	// cannot be parsed as a PARSE_ERROR finding (HIGH unless overridden in
+	// SeverityOverrides) instead of returning an error
+	ParseErrorsAsFindings bool
+
+	// SeverityOverrides sets the severity of findings by rule ID, taking
+	// precedence over the rules files and profiles
+	SeverityOverrides map[string]string
+
+	// ResolveLines makes ScanFile set Finding.Line from the source of local
+	// JSON, YAML, TOML, .env, .properties, and .ini files, at the cost of
+	// reading each file again
+	ResolveLines bool
+
+	// ScanComments makes ScanFile also search the raw content of local files
+	// for provider API keys that no parsed value holds, such as keys in
+	// comments, and report them as COMMENTED_SECRET findings with a line
+	ScanComments bool
+
+	stats statsCollector
 }
 
 // NewScanner creates a new scanner with loaded rules
 func NewScanner(rulesFile string) (*Scanner, error) {
-	data, err := os.ReadFile(rulesFile)
+	rules, err := loadRulesFile(rulesFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read rules file: %w", err)
+		return nil, err
 	}
 
-	var rules RulesFile
-	if err := yaml.Unmarshal(data, &rules); err != nil {
-		return nil, fmt.Errorf("failed to parse rules file: %w", err)
+	return newScanner(rules)
+}
+
+// newScanner resolves rule inheritance, merges each check's field into its
+// fields, and wraps the rules in a Scanner
+func newScanner(rules RulesFile) (*Scanner, error) {
+	stampVersion(&rules)
+	if err := resolveExtends(&rules); err != nil {
+		return nil, err
+	}
+	normalizeFields(&rules)
+	if err := validateCompound(rules); err != nil {
+		return nil, err
+	}
+	if err := validateProfiles(rules); err != nil {
+		return nil, err
+	}
+	if err := validateChecks(rules); err != nil {
+		return nil, err
+	}
+	if err := validateRecommendationTemplates(rules); err != nil {
+		return nil, err
 	}
 
 	return &Scanner{
@@ -29,36 +115,611 @@ func NewScanner(rulesFile string) (*Scanner, error) {
 	}, nil
 }
 
+//go:embed rules.yaml
+var defaultRules []byte
+
+// DefaultRules returns the bundled rules file as embedded in the binary
+func DefaultRules() []byte {
+	return append([]byte(nil), defaultRules...)
+}
+
+// NewScannerWithDefaults creates a scanner from the rule set embedded in the
+// binary, for use when no rules file is available
+func NewScannerWithDefaults() (*Scanner, error) {
+	var rules RulesFile
+	if err := yaml.Unmarshal(defaultRules, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse default rules: %w", err)
+	}
+	applyContextWindows(&rules)
+
+	return newScanner(rules)
+}
+
+// NewScannerFromFiles creates a scanner from several rules files merged in
+// order. A directory entry loads every .yaml/.yml file inside it, sorted by
+// name. Rule IDs must be unique across all files.
+func NewScannerFromFiles(paths []string) (*Scanner, error) {
+	var merged RulesFile
+	seenRules := make(map[string]string)
+	seenTemplates := make(map[string]string)
+	seenCategories := make(map[string]bool)
+
+	for _, path := range paths {
+		files, err := expandRulesPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			rules, err := loadRulesFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+
+			if merged.Version == "" {
+				merged.Version = rules.Version
+			}
+			for _, rule := range rules.Rules {
+				if prev, ok := seenRules[rule.ID]; ok {
+					return nil, fmt.Errorf("duplicate rule ID %q in %s (already defined in %s)", rule.ID, file, prev)
+				}
+				seenRules[rule.ID] = file
+				merged.Rules = append(merged.Rules, rule)
+			}
+			for _, template := range rules.Templates {
+				if prev, ok := seenTemplates[template.ID]; ok {
+					return nil, fmt.Errorf("duplicate template ID %q in %s (already defined in %s)", template.ID, file, prev)
+				}
+				seenTemplates[template.ID] = file
+				merged.Templates = append(merged.Templates, template)
+			}
+			merged.Profiles = append(merged.Profiles, rules.Profiles...)
+			merged.Compound = append(merged.Compound, rules.Compound...)
+			for _, category := range rules.Categories {
+				if !seenCategories[category] {
+					seenCategories[category] = true
+					merged.Categories = append(merged.Categories, category)
+				}
+			}
+		}
+	}
+
+	return newScanner(merged)
+}
+
+// NewScannerFromDir creates a scanner from every .yaml/.yml file in dir
+func NewScannerFromDir(dir string) (*Scanner, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return NewScannerFromFiles([]string{dir})
+}
+
+func loadRulesFile(path string) (RulesFile, error) {
+	var rules RulesFile
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return rules, fmt.Errorf("failed to read rules file: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("failed to parse rules file: %w", err)
+	}
+	applyContextWindows(&rules)
+	stampVersion(&rules)
+
+	return rules, nil
+}
+
+// expandRulesPath returns path itself for a file, or the sorted YAML files
+// directly inside it for a directory
+func expandRulesPath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if ext == ".yaml" || ext == ".yml" {
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .yaml or .yml rules files found in %s", path)
+	}
+	return files, nil
+}
+
+// stampVersion records the rules file's version on each of its rules that
+// does not have one yet, so merged rule sets keep the version of the file
+// each rule came from
+func stampVersion(rules *RulesFile) {
+	for _, list := range [][]Rule{rules.Rules, rules.Templates} {
+		for i := range list {
+			if list[i].RuleSetVersion == "" {
+				list[i].RuleSetVersion = rules.Version
+			}
+		}
+	}
+	for i := range rules.Compound {
+		if rules.Compound[i].RuleSetVersion == "" {
+			rules.Compound[i].RuleSetVersion = rules.Version
+		}
+	}
+}
+
+// Rules returns the loaded rules in file order
+func (s *Scanner) Rules() []Rule {
+	rules := make([]Rule, len(s.rules.Rules))
+	copy(rules, s.rules.Rules)
+	return rules
+}
+
+// Categories returns the categories declared in the rules files, followed
+// by any category a rule uses without it being declared
+func (s *Scanner) Categories() []string {
+	seen := make(map[string]bool)
+	var categories []string
+	for _, category := range s.rules.Categories {
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+	for _, rule := range s.rules.Rules {
+		if rule.Category != "" && !seen[rule.Category] {
+			seen[rule.Category] = true
+			categories = append(categories, rule.Category)
+		}
+	}
+	return categories
+}
+
 // ScanFile scans a configuration file
 func (s *Scanner) ScanFile(filePath string) (ScanResult, error) {
-	config, err := ParseConfigFile(filePath)
+	if s.CollectStats {
+		start := time.Now()
+		defer func() { s.stats.recordFile(filePath, time.Since(start)) }()
+	}
+
+	config, err := ParseConfigFileWithOptions(filePath, s.ParseOptions)
 	if err != nil {
+		if s.ParseErrorsAsFindings && !errors.Is(err, fs.ErrNotExist) {
+			return s.parseErrorResult(filePath, err), nil
+		}
 		return ScanResult{}, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	findings := []Finding{}
+	whole := config
+	selectedPath := ""
+	if s.ParseOptions.Select != "" {
+		if config, selectedPath, err = config.Select(s.ParseOptions.Select); err != nil {
+			return ScanResult{}, err
+		}
+	}
 
-	for _, rule := range s.rules.Rules {
-		if finding := CheckRule(rule, config); finding != nil {
-			findings = append(findings, *finding)
+	embedded, unwrapped, err := unwrapConfig(config, s.ParseOptions)
+	if err != nil {
+		if s.ParseErrorsAsFindings {
+			return s.parseErrorResult(filePath, fmt.Errorf("embedded config: %w", err)), nil
 		}
+		return ScanResult{}, fmt.Errorf("failed to parse embedded config: %w", err)
 	}
 
-	return ScanResult{
-		File:     filePath,
-		Findings: findings,
-	}, nil
+	profile := s.profileFor(filePath)
+	var findings []Finding
+	var passed []string
+	if unwrapped {
+		findings, passed = s.scanEmbedded(embedded, profile)
+	} else {
+		findings, passed = s.scanConfig(config, profile, false)
+	}
+	if s.ParseOptions.DecodeBase64Values && !(s.FailFast && len(findings) > 0) {
+		var decoded []embeddedConfig
+		if unwrapped {
+			for _, e := range embedded {
+				decoded = append(decoded, decodeBase64Configs(e.config, e.location, e.path, s.ParseOptions, 0)...)
+			}
+		} else {
+			decoded = decodeBase64Configs(config, "", "$", s.ParseOptions, 0)
+		}
+		if len(decoded) > 0 {
+			decodedFindings, decodedPassed := s.scanEmbedded(decoded, profile)
+			findings = append(findings, decodedFindings...)
+			passed = keepPassed(passed, decodedPassed)
+		}
+	}
+	if selectedPath != "" {
+		// Keep paths pointing into the whole file
+		for i := range findings {
+			if findings[i].Path != "" {
+				findings[i].Path = selectedPath + strings.TrimPrefix(findings[i].Path, "$")
+				findings[i].Pointer = JSONPointer(findings[i].Path)
+			}
+		}
+	}
+	if s.ResolveLines {
+		resolveLines(filePath, findings)
+	}
+	if s.ScanComments && !(s.FailFast && len(findings) > 0) {
+		findings = append(findings, s.commentedSecrets(filePath, whole)...)
+	}
+	findings = s.capFindings(findings)
+	if !(s.FailFast && len(findings) > 0) {
+		findings = s.addCompound(findings, profile)
+	}
+	sortFindings(findings)
+	setFingerprints(filePath, findings)
+	result := ScanResult{
+		File:      filePath,
+		Findings:  findings,
+		RiskScore: RiskScore(findings, s.RiskWeights),
+	}
+	if profile != nil {
+		result.Profile = profile.Name
+	}
+	if s.RecordPassed {
+		result.Passed = passed
+	}
+
+	return result, nil
 }
 
 // ScanConfig scans a parsed configuration
 func (s *Scanner) ScanConfig(config *Config) []Finding {
+	findings, _ := s.scanConfig(config, nil, false)
+	findings = s.capFindings(findings)
+	if !(s.FailFast && len(findings) > 0) {
+		findings = s.addCompound(findings, nil)
+	}
+	return findings
+}
+
+// Scan checks config against rules without reading anything from disk.
+// Rules are validated and resolved as NewScanner does, and findings are
+// sorted as ScanFile sorts them. rules is not modified.
+func Scan(rules RulesFile, config *Config) ([]Finding, error) {
+	rules = copyRulesFile(rules)
+	applyContextWindows(&rules)
+	s, err := newScanner(rules)
+	if err != nil {
+		return nil, err
+	}
+	findings := s.ScanConfig(config)
+	sortFindings(findings)
+	return findings, nil
+}
+
+// ScanWithRules parses a rules file and a config from memory and scans the
+// config. format names the config format by extension, with or without the
+// dot: json, yaml, toml, env, and the others ScanFile accepts.
+func ScanWithRules(rulesYAML []byte, data []byte, format string) ([]Finding, error) {
+	var rules RulesFile
+	if err := yaml.Unmarshal(rulesYAML, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+
+	ext := strings.ToLower(format)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if ext == ".gz" || !isKnownExtension(ext) {
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	configData, _, err := parseData(data, ext, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if configData == nil {
+		configData = make(map[string]interface{})
+	}
+
+	return Scan(rules, &Config{Data: configData})
+}
+
+// copyRulesFile copies the rule lists that loading modifies in place
+func copyRulesFile(rules RulesFile) RulesFile {
+	copyRules := func(list []Rule) []Rule {
+		copied := make([]Rule, len(list))
+		for i, rule := range list {
+			rule.Checks = append([]Check(nil), rule.Checks...)
+			copied[i] = rule
+		}
+		return copied
+	}
+	rules.Rules = copyRules(rules.Rules)
+	rules.Templates = copyRules(rules.Templates)
+	rules.Compound = append([]CompoundRule(nil), rules.Compound...)
+	return rules
+}
+
+// scanConfig returns the findings for config along with the IDs of the
+// rules that were evaluated and passed. A non-nil profile filters the rules
+// and overrides finding severities. A fragment, such as a decoded base64
+// value, is only part of a config, so rules that look for missing fields are
+// not run on it and count as passed, as do rules its applies_when skips.
+func (s *Scanner) scanConfig(config *Config, profile *Profile, fragment bool) ([]Finding, []string) {
 	findings := []Finding{}
+	passed := []string{}
 
 	for _, rule := range s.rules.Rules {
-		if finding := CheckRule(rule, config); finding != nil {
-			findings = append(findings, *finding)
+		if !s.shouldRun(rule) || !profile.allows(rule) {
+			continue
+		}
+		if fragment && rule.checksAbsence() {
+			passed = append(passed, rule.ID)
+			continue
+		}
+		// Rules gated by applies_when are skipped, not passed, except on a
+		// fragment, where skipping must not undo a pass from the rest of
+		// the file
+		if !rule.AppliesTo(config) {
+			if fragment {
+				passed = append(passed, rule.ID)
+			}
+			continue
+		}
+		var start time.Time
+		if s.CollectStats {
+			start = time.Now()
+		}
+		ruleFindings := CheckRule(rule, config)
+		if s.CollectStats {
+			s.stats.recordRule(rule.ID, time.Since(start))
+		}
+
+		if len(ruleFindings) == 0 {
+			passed = append(passed, rule.ID)
+		}
+		for i := range ruleFindings {
+			profile.apply(&ruleFindings[i])
+			s.overrideSeverity(&ruleFindings[i])
+			findings = append(findings, ruleFindings[i])
+		}
+		if s.FailFast && len(findings) > 0 {
+			return findings[:1], passed
 		}
 	}
 
-	return findings
+	for _, path := range config.DuplicateKeys {
+		finding := duplicateKeyFinding(path)
+		s.overrideSeverity(&finding)
+		findings = append(findings, finding)
+		if s.FailFast {
+			return findings, passed
+		}
+	}
+
+	return findings, passed
+}
+
+// scanEmbedded scans each config unwrapped from a file, prefixing finding
+// locations with where the config was embedded. A rule passes only if it
+// passed for every embedded config.
+func (s *Scanner) scanEmbedded(embedded []embeddedConfig, profile *Profile) ([]Finding, []string) {
+	findings := []Finding{}
+	passCounts := make(map[string]int)
+	var order []string
+
+	for _, e := range embedded {
+		configFindings, configPassed := s.scanConfig(e.config, profile, e.fragment)
+		for _, finding := range configFindings {
+			findings = append(findings, e.relocate(finding))
+		}
+		if s.FailFast && len(findings) > 0 {
+			return findings, []string{}
+		}
+		for _, id := range configPassed {
+			if passCounts[id] == 0 {
+				order = append(order, id)
+			}
+			passCounts[id]++
+		}
+	}
+
+	passed := []string{}
+	for _, id := range order {
+		if passCounts[id] == len(embedded) {
+			passed = append(passed, id)
+		}
+	}
+	return findings, passed
+}
+
+// keepPassed returns the rules in passed that also passed in other, in the
+// order of passed
+func keepPassed(passed, other []string) []string {
+	alsoPassed := make(map[string]bool, len(other))
+	for _, id := range other {
+		alsoPassed[id] = true
+	}
+	kept := []string{}
+	for _, id := range passed {
+		if alsoPassed[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+// parseErrorResult reports a file that could not be parsed as a result
+// holding a single PARSE_ERROR finding
+func (s *Scanner) parseErrorResult(filePath string, err error) ScanResult {
+	finding := Finding{
+		RuleID:         ParseErrorRuleID,
+		Name:           "Unparseable Config File",
+		Severity:       "HIGH",
+		Category:       "configuration",
+		Description:    "The file could not be parsed, so none of its settings were checked.",
+		Detail:         err.Error(),
+		Recommendation: "Fix the syntax error so the file can be scanned.",
+		References:     []string{},
+	}
+	s.overrideSeverity(&finding)
+	findings := []Finding{finding}
+	if !s.FailFast {
+		findings = s.addCompound(findings, s.profileFor(filePath))
+		sortFindings(findings)
+	}
+	setFingerprints(filePath, findings)
+
+	result := ScanResult{
+		File:      filePath,
+		Findings:  findings,
+		RiskScore: RiskScore(findings, s.RiskWeights),
+	}
+	if s.RecordPassed {
+		result.Passed = []string{}
+	}
+	return result
+}
+
+// sortFindings orders findings by severity, most severe first, then by rule
+// ID, location and path, so a file always reports its findings in the same
+// order
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if rankA, rankB := SeverityRank(a.Severity), SeverityRank(b.Severity); rankA != rankB {
+			return rankA > rankB
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		return a.Path < b.Path
+	})
+}
+
+// capFindings keeps the first max_findings findings of each rule that sets
+// it, in the order they were found, and adds a TruncatedRuleID finding for
+// each rule that had more. The note takes the highest severity among the
+// dropped findings so it is as visible as they would have been.
+func (s *Scanner) capFindings(findings []Finding) []Finding {
+	limits := make(map[string]int)
+	for _, rule := range s.rules.Rules {
+		if rule.MaxFindings > 0 {
+			limits[rule.ID] = rule.MaxFindings
+		}
+	}
+	if len(limits) == 0 {
+		return findings
+	}
+
+	counts := make(map[string]int)
+	dropped := make(map[string][]Finding)
+	var truncated []string
+	kept := make([]Finding, 0, len(findings))
+	for _, finding := range findings {
+		limit, ok := limits[finding.RuleID]
+		if !ok || counts[finding.RuleID] < limit {
+			counts[finding.RuleID]++
+			kept = append(kept, finding)
+			continue
+		}
+		if len(dropped[finding.RuleID]) == 0 {
+			truncated = append(truncated, finding.RuleID)
+		}
+		dropped[finding.RuleID] = append(dropped[finding.RuleID], finding)
+	}
+
+	for _, id := range truncated {
+		finding := truncatedFinding(id, limits[id], dropped[id])
+		s.overrideSeverity(&finding)
+		kept = append(kept, finding)
+	}
+	return kept
+}
+
+func truncatedFinding(ruleID string, limit int, dropped []Finding) Finding {
+	severity := dropped[0].Severity
+	for _, finding := range dropped {
+		if SeverityRank(finding.Severity) > SeverityRank(severity) {
+			severity = finding.Severity
+		}
+	}
+	return Finding{
+		RuleID:         TruncatedRuleID,
+		Name:           "Findings Truncated",
+		Severity:       severity,
+		Category:       dropped[0].Category,
+		Description:    fmt.Sprintf("%d more %s finding(s) not reported (max_findings %d).", len(dropped), ruleID, limit),
+		Location:       ruleID,
+		Recommendation: "Narrow the rule's patterns or fields, or raise max_findings, to see every finding.",
+		References:     []string{},
+	}
+}
+
+func duplicateKeyFinding(path string) Finding {
+	return Finding{
+		RuleID:         DuplicateKeyRuleID,
+		Name:           "Duplicate Key in JSON",
+		Severity:       "HIGH",
+		Category:       "configuration",
+		Description:    "The same key is defined more than once. JSON parsers silently keep the last value, which can hide an unsafe override.",
+		Location:       path,
+		Recommendation: "Remove the duplicate so the key is defined exactly once.",
+		References:     []string{"RFC 8259 Section 4 - names within an object SHOULD be unique"},
+	}
+}
+
+// shouldRun reports whether a rule is enabled and passes the tag filter
+func (s *Scanner) shouldRun(rule Rule) bool {
+	if !rule.IsEnabled() {
+		return false
+	}
+	if len(s.Tags) == 0 {
+		return true
+	}
+	for _, tag := range s.Tags {
+		if rule.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideSeverity applies SeverityOverrides to a finding
+func (s *Scanner) overrideSeverity(finding *Finding) {
+	for id, severity := range s.SeverityOverrides {
+		if strings.EqualFold(id, finding.RuleID) {
+			finding.Severity = strings.ToUpper(severity)
+			return
+		}
+	}
+}
+
+// Fix clamps values that violate numeric_range rules in config, returning
+// the changes made. Only rules a scan of config would run are applied: rules
+// filtered out by Tags or by the profile for config.FilePath are skipped.
+// Use EncodeConfig to serialize the result.
+func (s *Scanner) Fix(config *Config) []Fix {
+	profile := s.profileFor(config.FilePath)
+	var rules []Rule
+	for _, rule := range s.rules.Rules {
+		if s.shouldRun(rule) && profile.allows(rule) {
+			rules = append(rules, rule)
+		}
+	}
+	return ApplyFixes(rules, config)
 }
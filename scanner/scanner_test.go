@@ -1,8 +1,12 @@
 package scanner
 
 import (
+	"encoding/base64"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -147,3 +151,1485 @@ func TestNewScanner_InvalidRulesFile(t *testing.T) {
 		})
 	}
 }
+
+func TestScanner_Fix(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+  - id: TOKENS_001
+    name: "Too Many Tokens"
+    severity: MEDIUM
+    check:
+      type: numeric_range
+      path: limits.max_tokens
+      min: 1
+      max: 4096
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+	}{
+		{
+			name:     "json",
+			filename: "config.json",
+			content:  `{"model": "gpt-4", "temperature": 1.5, "limits": {"max_tokens": 10000}}`,
+		},
+		{
+			name:     "yaml",
+			filename: "config.yaml",
+			content:  "model: gpt-4\ntemperature: 1.5\nlimits:\n  max_tokens: 10000\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(tmpDir, tt.filename)
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config file: %v", err)
+			}
+
+			config, err := ParseConfigFile(configFile)
+			if err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+
+			fixes := s.Fix(config)
+			if len(fixes) != 2 {
+				t.Fatalf("expected 2 fixes, got %d: %+v", len(fixes), fixes)
+			}
+
+			data, err := EncodeConfig(config)
+			if err != nil {
+				t.Fatalf("EncodeConfig() error = %v", err)
+			}
+
+			// Re-parse the fixed output in the same format
+			fixedFile := filepath.Join(tmpDir, "fixed-"+tt.filename)
+			if err := os.WriteFile(fixedFile, data, 0644); err != nil {
+				t.Fatalf("failed to write fixed file: %v", err)
+			}
+			fixed, err := ParseConfigFile(fixedFile)
+			if err != nil {
+				t.Fatalf("fixed output does not parse: %v\n%s", err, data)
+			}
+
+			if temp, _ := toFloat(fixed.Data["temperature"]); temp != 1.0 {
+				t.Errorf("temperature = %v, want 1.0", fixed.Data["temperature"])
+			}
+			if tokens, _ := fixed.GetValue("limits.max_tokens"); tokens != float64(4096) && tokens != 4096 {
+				t.Errorf("limits.max_tokens = %#v, want 4096", tokens)
+			}
+			if fixed.Data["model"] != "gpt-4" {
+				t.Errorf("model = %v, want unchanged gpt-4", fixed.Data["model"])
+			}
+			if result := s.ScanConfig(fixed); len(result) != 0 {
+				t.Errorf("expected no findings after fix, got %d", len(result))
+			}
+		})
+	}
+}
+
+func TestScanner_FixSkipsRulesThatDoNotRun(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name    string
+		modify  func(rule *Rule)
+		tags    []string
+		file    string
+		wantFix bool
+	}{
+		{name: "runs", file: "config.json", wantFix: true},
+		{name: "disabled", modify: func(rule *Rule) { rule.Enabled = &disabled }, file: "config.json"},
+		{name: "filtered by tag", tags: []string{"secrets"}, file: "config.json"},
+		{name: "disabled by profile", file: "config.dev.json"},
+		{name: "negated", modify: func(rule *Rule) { rule.Check.Negate = true }, file: "config.json"},
+		{
+			name: "applies_when does not hold",
+			modify: func(rule *Rule) {
+				rule.AppliesWhen = []Condition{{Parameter: "model", Operator: "equals", Value: "gpt-4"}}
+			},
+			file: "config.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := Rule{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1}}
+			if tt.modify != nil {
+				tt.modify(&rule)
+			}
+			s, err := newScanner(RulesFile{
+				Rules:    []Rule{rule},
+				Profiles: []Profile{{Name: "dev", Files: []string{"*.dev.json"}, Disable: []string{"TEMP_001"}}},
+			})
+			if err != nil {
+				t.Fatalf("failed to create scanner: %v", err)
+			}
+			s.Tags = tt.tags
+
+			config := &Config{Data: map[string]interface{}{"model": "claude", "temperature": 1.5}, FilePath: tt.file}
+			fixes := s.Fix(config)
+			if tt.wantFix && len(fixes) != 1 {
+				t.Errorf("expected one fix, got %+v", fixes)
+			}
+			if !tt.wantFix && len(fixes) != 0 {
+				t.Errorf("expected no fixes, got %+v", fixes)
+			}
+		})
+	}
+}
+
+func TestScanner_RuleMetadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: SEED_001
+    name: "Seed Present"
+    severity: MEDIUM
+    enabled: false
+    check:
+      type: field_exists
+      field: seed
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    tags: [sampling]
+    cwe: CWE-1188
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+  - id: SECRETS_001
+    name: "API Key Found"
+    severity: CRITICAL
+    enabled: true
+    tags: [secrets]
+    check:
+      type: field_exists
+      field: api_key
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	config := &Config{Data: map[string]interface{}{
+		"seed":        42,
+		"temperature": 1.5,
+		"api_key":     "sk-test",
+	}}
+
+	ruleIDs := func(findings []Finding) []string {
+		ids := []string{}
+		for _, f := range findings {
+			ids = append(ids, f.RuleID)
+		}
+		return ids
+	}
+
+	findings := s.ScanConfig(config)
+	if ids := ruleIDs(findings); len(ids) != 2 || ids[0] != "TEMP_001" || ids[1] != "SECRETS_001" {
+		t.Errorf("expected disabled SEED_001 to be skipped, got %v", ids)
+	}
+	if findings[0].CWE != "CWE-1188" {
+		t.Errorf("CWE = %q, want CWE-1188", findings[0].CWE)
+	}
+
+	s.Tags = []string{"secrets"}
+	if ids := ruleIDs(s.ScanConfig(config)); len(ids) != 1 || ids[0] != "SECRETS_001" {
+		t.Errorf("expected only secrets-tagged rules, got %v", ids)
+	}
+
+	s.Tags = []string{"unknown"}
+	if ids := ruleIDs(s.ScanConfig(config)); len(ids) != 0 {
+		t.Errorf("expected no rules for an unknown tag, got %v", ids)
+	}
+}
+
+func TestScanner_DuplicateKeyFinding(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte("version: \"1.0.0\"\nrules: []\n"), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	configFile := filepath.Join(tmpDir, "config.json")
+	if err := os.WriteFile(configFile, []byte(`{"temperature": 0.2, "temperature": 1.9}`), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	s.ParseOptions.StrictJSON = true
+
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if len(result.Findings) != 1 {
+		t.Fatalf("expected 1 finding, got %d", len(result.Findings))
+	}
+	if f := result.Findings[0]; f.RuleID != DuplicateKeyRuleID || f.Location != "temperature" {
+		t.Errorf("unexpected finding: %+v", f)
+	}
+}
+
+func TestScanner_RecordPassed(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      min: 0.0
+      max: 1.0
+  - id: SEED_001
+    name: "Seed Present"
+    severity: LOW
+    check:
+      type: field_exists
+      field: seed
+  - id: OFF_001
+    name: "Disabled Rule"
+    severity: LOW
+    enabled: false
+    check:
+      type: field_exists
+      field: model
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	configFile := filepath.Join(tmpDir, "safe.json")
+	if err := os.WriteFile(configFile, []byte(`{"model": "gpt-4", "temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if result.Passed != nil {
+		t.Errorf("Passed should be empty unless RecordPassed is set, got %v", result.Passed)
+	}
+
+	s.RecordPassed = true
+	result, err = s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+
+	// Disabled rules were not evaluated, so they are not listed as passed
+	want := []string{"TEMP_001", "SEED_001"}
+	if len(result.Passed) != len(want) {
+		t.Fatalf("Passed = %v, want %v", result.Passed, want)
+	}
+	for i := range want {
+		if result.Passed[i] != want[i] {
+			t.Errorf("Passed[%d] = %s, want %s", i, result.Passed[i], want[i])
+		}
+	}
+}
+
+func TestNewScannerFromFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeRules := func(name, id string) string {
+		path := filepath.Join(tmpDir, name)
+		content := `
+version: "1.0.0"
+categories:
+  - parameters
+rules:
+  - id: ` + id + `
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write rules file: %v", err)
+		}
+		return path
+	}
+
+	first := writeRules("a.yaml", "TEST_001")
+	second := writeRules("b.yml", "TEST_002")
+
+	t.Run("merges files", func(t *testing.T) {
+		s, err := NewScannerFromFiles([]string{first, second})
+		if err != nil {
+			t.Fatalf("NewScannerFromFiles() error = %v", err)
+		}
+		rules := s.Rules()
+		if len(rules) != 2 || rules[0].ID != "TEST_001" || rules[1].ID != "TEST_002" {
+			t.Errorf("unexpected merged rules: %+v", rules)
+		}
+		if len(s.rules.Categories) != 1 {
+			t.Errorf("categories should be deduplicated, got %v", s.rules.Categories)
+		}
+	})
+
+	t.Run("loads directory", func(t *testing.T) {
+		s, err := NewScannerFromDir(tmpDir)
+		if err != nil {
+			t.Fatalf("NewScannerFromDir() error = %v", err)
+		}
+		if len(s.Rules()) != 2 {
+			t.Errorf("expected 2 rules from directory, got %d", len(s.Rules()))
+		}
+	})
+
+	t.Run("rejects duplicate IDs", func(t *testing.T) {
+		conflict := writeRules("conflict.yaml", "TEST_001")
+		_, err := NewScannerFromFiles([]string{first, conflict})
+		if err == nil {
+			t.Fatal("expected an error for duplicate rule IDs")
+		}
+		if !strings.Contains(err.Error(), "TEST_001") || !strings.Contains(err.Error(), "conflict.yaml") {
+			t.Errorf("error should name the ID and file, got %v", err)
+		}
+	})
+}
+
+func TestNewScannerWithDefaults(t *testing.T) {
+	s, err := NewScannerWithDefaults()
+	if err != nil {
+		t.Fatalf("NewScannerWithDefaults() error = %v", err)
+	}
+	if len(s.Rules()) == 0 {
+		t.Fatal("expected embedded rules to be loaded")
+	}
+
+	findings := s.ScanConfig(&Config{Data: map[string]interface{}{
+		"api_key": "sk-abcdefghijklmnopqrstuvwxyz123456",
+	}})
+	found := false
+	for _, f := range findings {
+		if f.RuleID == "SECRETS_001" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected SECRETS_001 for an API key config, got %+v", findings)
+	}
+}
+
+func TestScanner_Stats(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEST_001
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TEST_002
+    severity: CRITICAL
+    check:
+      type: pattern_match
+      patterns:
+        - "sk-[a-zA-Z0-9]{10,}"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	var configs []string
+	for _, name := range []string{"a.json", "b.json"} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(`{"temperature": 1.5}`), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		configs = append(configs, path)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	if _, err := s.ScanFile(configs[0]); err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if stats := s.Stats(); len(stats.Rules) != 0 || len(stats.Files) != 0 {
+		t.Errorf("stats should be empty unless CollectStats is set, got %+v", stats)
+	}
+
+	s.CollectStats = true
+	for _, config := range configs {
+		if _, err := s.ScanFile(config); err != nil {
+			t.Fatalf("ScanFile() error = %v", err)
+		}
+	}
+
+	stats := s.Stats()
+	if len(stats.Rules) != 2 {
+		t.Fatalf("expected stats for 2 rules, got %+v", stats.Rules)
+	}
+	for _, rule := range stats.Rules {
+		if rule.Evaluations != 2 {
+			t.Errorf("%s evaluations = %d, want 2", rule.RuleID, rule.Evaluations)
+		}
+		if rule.Total < 0 {
+			t.Errorf("%s total = %v, want non-negative", rule.RuleID, rule.Total)
+		}
+	}
+	if len(stats.Files) != 2 {
+		t.Fatalf("expected stats for 2 files, got %+v", stats.Files)
+	}
+	for i, file := range stats.Files {
+		if file.Duration < 0 {
+			t.Errorf("%s duration = %v, want non-negative", file.File, file.Duration)
+		}
+		if i > 0 && file.Duration > stats.Files[i-1].Duration {
+			t.Error("files should be ordered slowest first")
+		}
+	}
+}
+
+func TestScanner_Profiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEST_001
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TEST_002
+    severity: CRITICAL
+    category: secrets
+    check:
+      type: pattern_match
+      patterns:
+        - "sk-[a-zA-Z0-9]{10,}"
+profiles:
+  - name: dev
+    files: ["*.dev.json"]
+    disable: [TEST_001]
+    severity:
+      - category: secrets
+        from: CRITICAL
+        to: LOW
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	content := []byte(`{"temperature": 1.5, "api_key": "sk-test1234567890"}`)
+	prodFile := filepath.Join(tmpDir, "config.json")
+	devFile := filepath.Join(tmpDir, "config.dev.json")
+	for _, path := range []string{prodFile, devFile} {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+
+	severities := func(result ScanResult) map[string]string {
+		got := make(map[string]string)
+		for _, f := range result.Findings {
+			got[f.RuleID] = f.Severity
+		}
+		return got
+	}
+
+	prod, err := s.ScanFile(prodFile)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if got := severities(prod); got["TEST_001"] != "HIGH" || got["TEST_002"] != "CRITICAL" || prod.Profile != "" {
+		t.Errorf("prod config should use rule defaults, got %v (profile %q)", got, prod.Profile)
+	}
+
+	dev, err := s.ScanFile(devFile)
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	got := severities(dev)
+	if _, ok := got["TEST_001"]; ok {
+		t.Error("TEST_001 should be disabled by the dev profile")
+	}
+	if got["TEST_002"] != "LOW" {
+		t.Errorf("TEST_002 severity = %q, want LOW under the dev profile", got["TEST_002"])
+	}
+	if dev.Profile != "dev" {
+		t.Errorf("Profile = %q, want dev", dev.Profile)
+	}
+
+	// Bad severities and globs fail loading
+	invalid := []Profile{
+		{Name: "typo", Files: []string{"*.json"}, Severity: []SeverityOverride{{To: "lwo"}}},
+		{Name: "typo", Files: []string{"*.json"}, Severity: []SeverityOverride{{From: "hihg", To: "LOW"}}},
+		{Name: "missing", Files: []string{"*.json"}, Severity: []SeverityOverride{{Category: "secrets"}}},
+		{Name: "glob", Files: []string{"[*.json"}},
+	}
+	for _, profile := range invalid {
+		if _, err := newScanner(RulesFile{Profiles: []Profile{profile}}); err == nil {
+			t.Errorf("expected an error for profile %+v", profile)
+		}
+	}
+}
+
+func TestNewScanner_Extends(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write rules file: %v", err)
+		}
+		return path
+	}
+
+	rulesFile := write("rules.yaml", `
+version: "1.0.0"
+templates:
+  - id: SAMPLING_BASE
+    severity: MEDIUM
+    category: parameters
+    recommendation: "Keep sampling parameters in the documented safe range"
+    references:
+      - "Sampling reference"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    extends: SAMPLING_BASE
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TOPP_001
+    name: "High top_p"
+    extends: TEMP_001
+    check:
+      type: numeric_range
+      parameter: top_p
+      max: 0.95
+`)
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("NewScanner() error = %v", err)
+	}
+
+	rules := s.Rules()
+	if len(rules) != 2 {
+		t.Fatalf("templates should not be scanned as rules, got %d rules", len(rules))
+	}
+	temp, topP := rules[0], rules[1]
+
+	if temp.Severity != "HIGH" {
+		t.Errorf("TEMP_001 severity = %q, want the override HIGH", temp.Severity)
+	}
+	if temp.Category != "parameters" || len(temp.References) != 1 || temp.References[0] != "Sampling reference" {
+		t.Errorf("TEMP_001 should inherit category and references, got %+v", temp)
+	}
+	if temp.Check.Parameter != "temperature" {
+		t.Errorf("TEMP_001 should keep its own check, got %+v", temp.Check)
+	}
+	if topP.Severity != "HIGH" || topP.Recommendation != "Keep sampling parameters in the documented safe range" {
+		t.Errorf("TOPP_001 should inherit through TEMP_001, got %+v", topP)
+	}
+
+	cyclic := write("cyclic.yaml", `
+rules:
+  - id: A
+    extends: B
+  - id: B
+    extends: A
+`)
+	if _, err := NewScanner(cyclic); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+
+	unknown := write("unknown.yaml", `
+rules:
+  - id: A
+    extends: MISSING
+`)
+	if _, err := NewScanner(unknown); err == nil || !strings.Contains(err.Error(), "MISSING") {
+		t.Errorf("expected an unknown base error, got %v", err)
+	}
+}
+
+func TestScanner_Categories(t *testing.T) {
+	s, err := newScanner(RulesFile{
+		Categories: []string{"secrets", "parameters"},
+		Rules: []Rule{
+			{ID: "A", Category: "parameters"},
+			{ID: "B", Category: "monitoring"},
+			{ID: "C", Category: "secrets"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("newScanner: %v", err)
+	}
+
+	want := []string{"secrets", "parameters", "monitoring"}
+	if got := s.Categories(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Categories() = %v, want %v", got, want)
+	}
+}
+
+func TestScanner_CompoundRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    category: parameters
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: SECRETS_001
+    name: "API Key"
+    severity: HIGH
+    category: secrets
+    fields: [api_key]
+    check:
+      type: pattern_match
+      patterns: ["sk-[a-zA-Z0-9]{10,}"]
+  - id: RATE_001
+    name: "No Rate Limit"
+    severity: MEDIUM
+    category: rate_limiting
+    check:
+      type: missing_field
+      field: rate_limit
+compound:
+  - id: COMPOUND_001
+    name: "Systemic Misconfiguration"
+    category: configuration
+    requires: [TEMP_001, SECRETS_001, RATE_001]
+    recommendation: "Fix the underlying findings"
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		config       string
+		wantCompound bool
+	}{
+		{
+			name:         "all three triggers fire",
+			config:       `{"temperature": 1.9, "api_key": "sk-abcdefghijklmnop"}`,
+			wantCompound: true,
+		},
+		{
+			name:         "one trigger missing",
+			config:       `{"temperature": 1.9, "api_key": "sk-abcdefghijklmnop", "rate_limit": {"rpm": 60}}`,
+			wantCompound: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(tmpDir, "config.json")
+			if err := os.WriteFile(configFile, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			result, err := s.ScanFile(configFile)
+			if err != nil {
+				t.Fatalf("ScanFile: %v", err)
+			}
+
+			var compound *Finding
+			for i := range result.Findings {
+				if result.Findings[i].RuleID == "COMPOUND_001" {
+					compound = &result.Findings[i]
+				}
+			}
+			if (compound != nil) != tt.wantCompound {
+				t.Fatalf("compound finding present = %v, want %v (findings: %+v)", compound != nil, tt.wantCompound, result.Findings)
+			}
+			if compound == nil {
+				return
+			}
+			if len(result.Findings) != 4 {
+				t.Errorf("expected 3 findings plus the compound one, got %d", len(result.Findings))
+			}
+			if compound.Severity != "CRITICAL" {
+				t.Errorf("Severity = %q, want CRITICAL", compound.Severity)
+			}
+			if compound.Location != "TEMP_001 + SECRETS_001 + RATE_001" {
+				t.Errorf("Location = %q", compound.Location)
+			}
+		})
+	}
+
+	// Compound rules must list their triggers
+	if _, err := newScanner(RulesFile{Compound: []CompoundRule{{ID: "COMPOUND_002"}}}); err == nil {
+		t.Error("expected an error for a compound rule without requires")
+	}
+
+	// Compound rules may only require rules that exist
+	unknown := RulesFile{
+		Rules:    []Rule{{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature"}}},
+		Compound: []CompoundRule{{ID: "COMPOUND_002", Requires: []string{"TEMP_001", "TEMP_01"}}},
+	}
+	if _, err := newScanner(unknown); err == nil || !strings.Contains(err.Error(), `"TEMP_01"`) {
+		t.Errorf("expected an error naming the unknown rule TEMP_01, got %v", err)
+	}
+	unknown.Compound[0].Requires = []string{"TEMP_001", DuplicateKeyRuleID}
+	if _, err := newScanner(unknown); err != nil {
+		t.Errorf("requiring the scanner's own findings should load, got %v", err)
+	}
+}
+
+func TestScanner_CompoundRulesSeeWholeFile(t *testing.T) {
+	s, err := newScanner(RulesFile{
+		Rules: []Rule{
+			{ID: "TEMP_001", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+			{ID: "DEBUG_001", Severity: "LOW", Check: Check{Type: "field_check", Field: "log_level", Values: []interface{}{"debug"}}},
+		},
+		Compound: []CompoundRule{
+			{ID: "COMPOUND_DECODED", Requires: []string{"TEMP_001", "DEBUG_001"}},
+			{ID: "COMPOUND_COMMENT", Requires: []string{"TEMP_001", CommentedSecretRuleID}},
+			{ID: "COMPOUND_PARSE", Requires: []string{ParseErrorRuleID}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	s.ParseOptions.DecodeBase64Values = true
+	s.ScanComments = true
+	s.ParseErrorsAsFindings = true
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("log_level: debug\nregion: us-east-1\n"))
+	tmpDir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "trigger in a decoded value",
+			file:    "decoded.yaml",
+			content: "temperature: 1.5\nannotations:\n  meta: " + encoded + "\n",
+			want:    []string{"COMPOUND_DECODED"},
+		},
+		{
+			name:    "trigger in a comment",
+			file:    "comment.yaml",
+			content: "temperature: 1.5\n# api_key: sk-ant-REDACTED\n",
+			want:    []string{"COMPOUND_COMMENT"},
+		},
+		{
+			name:    "unparseable file",
+			file:    "broken.json",
+			content: `{"temperature": `,
+			want:    []string{"COMPOUND_PARSE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			result, err := s.ScanFile(path)
+			if err != nil {
+				t.Fatalf("ScanFile: %v", err)
+			}
+			var got []string
+			for _, id := range findingIDs(result.Findings) {
+				if strings.HasPrefix(id, "COMPOUND_") {
+					got = append(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("compound findings = %v, want %v (findings: %v)", got, tt.want, findingIDs(result.Findings))
+			}
+		})
+	}
+}
+
+func TestScanner_MaxFindings(t *testing.T) {
+	rule := Rule{
+		ID:       "SECRET_001",
+		Severity: "HIGH",
+		Category: "secrets",
+		Check:    Check{Type: "pattern_match", Patterns: []string{`sk-[0-9]{20}`}},
+		Fields:   []string{"api_keys"},
+	}
+	keys := make([]string, 10)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("%q", fmt.Sprintf("sk-%020d", i))
+	}
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	content := `{"api_keys": [` + strings.Join(keys[:8], ", ") + `], "annotations": {"meta": "` +
+		base64.StdEncoding.EncodeToString([]byte(`{"api_keys": [`+strings.Join(keys[8:], ", ")+`]}`)) + `"}}`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	scan := func(maxFindings int) []Finding {
+		rule.MaxFindings = maxFindings
+		s, err := newScanner(RulesFile{Rules: []Rule{rule}})
+		if err != nil {
+			t.Fatalf("failed to create scanner: %v", err)
+		}
+		s.ParseOptions.DecodeBase64Values = true
+		result, err := s.ScanFile(configFile)
+		if err != nil {
+			t.Fatalf("ScanFile: %v", err)
+		}
+		return result.Findings
+	}
+
+	if findings := scan(0); len(findings) != 10 {
+		t.Fatalf("expected 10 findings without a cap, got %d", len(findings))
+	}
+
+	// The cap covers the whole file, decoded values included, and the
+	// truncation is its own finding so every format shows it
+	findings := scan(3)
+	if len(findings) != 4 {
+		t.Fatalf("expected 3 findings and a truncation note, got %v", findingIDs(findings))
+	}
+	var note *Finding
+	for i := range findings {
+		if findings[i].RuleID == TruncatedRuleID {
+			note = &findings[i]
+		}
+	}
+	if note == nil {
+		t.Fatalf("expected a %s finding, got %v", TruncatedRuleID, findingIDs(findings))
+	}
+	want := "7 more SECRET_001 finding(s) not reported (max_findings 3)."
+	if note.Description != want || note.Severity != "HIGH" || note.Location != "SECRET_001" {
+		t.Errorf("note = %q at %s (%s), want %q at HIGH (SECRET_001)", note.Description, note.Severity, note.Location, want)
+	}
+
+	if findings := scan(10); len(findings) != 10 {
+		t.Errorf("expected no truncation at the cap, got %v", findingIDs(findings))
+	}
+}
+
+func TestScanner_Select(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: RATE_001
+    name: "Missing Rate Limit"
+    severity: HIGH
+    check:
+      type: missing_field
+      field: rate_limit
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	configFile := filepath.Join(tmpDir, "app.yaml")
+	configContent := `
+services:
+  llm:
+    temperature: 0.5
+  batch:
+    temperature: 1.8
+    rate_limit: 100
+`
+	if err := os.WriteFile(configFile, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	// Without --select the batch block's values satisfy and trip the rules
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := findingIDs(result.Findings); len(ids) != 1 || ids[0] != "TEMP_001" {
+		t.Errorf("expected only TEMP_001 for the whole file, got %v", ids)
+	}
+
+	s.ParseOptions.Select = "services.llm"
+	result, err = s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := findingIDs(result.Findings); len(ids) != 1 || ids[0] != "RATE_001" {
+		t.Errorf("expected only RATE_001 for the llm block, got %v", ids)
+	}
+
+	s.ParseOptions.Select = "services.batch"
+	result, err = s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Findings) != 1 || result.Findings[0].Path != "$.services.batch.temperature" || result.Findings[0].Pointer != "/services/batch/temperature" {
+		t.Errorf("expected paths to point into the whole file, got %+v", result.Findings)
+	}
+
+	s.ParseOptions.Select = "services.batch.rate_limit"
+	if _, err := s.ScanFile(configFile); err == nil || !strings.Contains(err.Error(), "not a map") {
+		t.Errorf("expected an error selecting a non-map value, got %v", err)
+	}
+}
+
+func findingIDs(findings []Finding) []string {
+	ids := make([]string, len(findings))
+	for i, finding := range findings {
+		ids[i] = finding.RuleID
+	}
+	return ids
+}
+
+func TestScanner_AppliesWhenSkipsRule(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{
+			ID:          "RATE_001",
+			Check:       Check{Type: "missing_field", Field: "rate_limit"},
+			AppliesWhen: []Condition{{Parameter: "deployment", Operator: "equals", Value: "production"}},
+		},
+		{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	dev := filepath.Join(tmpDir, "dev.json")
+	prod := filepath.Join(tmpDir, "prod.json")
+	if err := os.WriteFile(dev, []byte(`{"deployment": "dev", "temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := os.WriteFile(prod, []byte(`{"deployment": "production", "temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	s.RecordPassed = true
+
+	result, err := s.ScanFile(dev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Findings) != 0 {
+		t.Errorf("expected no findings for dev, got %v", findingIDs(result.Findings))
+	}
+	if len(result.Passed) != 1 || result.Passed[0] != "TEMP_001" {
+		t.Errorf("expected the skipped rule to be left out of passed, got %v", result.Passed)
+	}
+
+	result, err = s.ScanFile(prod)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ids := findingIDs(result.Findings); len(ids) != 1 || ids[0] != "RATE_001" {
+		t.Errorf("expected RATE_001 for prod, got %v", ids)
+	}
+}
+
+func TestNewScanner_ContextWindowsTable(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+context_windows:
+  acme-llm: 4096
+  gpt-4o: 1000
+rules:
+  - id: TOKENS_004
+    name: "Max Tokens Exceed Model Context Window"
+    severity: MEDIUM
+    check:
+      type: context_window_exceeded
+      context_windows:
+        gpt-4o: 2000
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tests := []struct {
+		model       string
+		maxTokens   float64
+		wantViolate bool
+	}{
+		{"acme-llm", 5000, true},
+		{"acme-llm", 3000, false},
+		{"gpt-4o", 1500, false}, // the check's own table wins over the file's
+		{"gpt-4o", 2500, true},
+		{"gpt-4", 9000, true}, // built-in table still applies
+	}
+	for _, tt := range tests {
+		config := &Config{Data: map[string]interface{}{"model": tt.model, "max_tokens": tt.maxTokens}}
+		if violated := len(s.ScanConfig(config)) > 0; violated != tt.wantViolate {
+			t.Errorf("%s with max_tokens %v: violated = %v, want %v", tt.model, tt.maxTokens, violated, tt.wantViolate)
+		}
+	}
+}
+
+func TestScanner_FailFast(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+		{ID: "RATE_001", Check: Check{Type: "missing_field", Field: "rate_limit"}},
+		{ID: "SECRET_001", Check: Check{Type: "pattern_match", Patterns: []string{"sk-[a-z]{10,}"}}, Fields: []string{"api_key"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	config := &Config{Data: map[string]interface{}{"temperature": 1.5, "api_key": "sk-abcdefghijkl"}}
+
+	if findings := s.ScanConfig(config); len(findings) != 3 {
+		t.Fatalf("expected 3 findings without FailFast, got %v", findingIDs(findings))
+	}
+
+	s.FailFast = true
+	findings := s.ScanConfig(config)
+	if ids := findingIDs(findings); len(ids) != 1 || ids[0] != "TEMP_001" {
+		t.Errorf("expected only the first finding with FailFast, got %v", ids)
+	}
+}
+
+func TestScanner_SeverityOverrides(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "SEED_001", Severity: "MEDIUM", Check: Check{Type: "field_exists", Field: "seed"}},
+		{ID: "TEMP_001", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	config := &Config{Data: map[string]interface{}{"seed": 42, "temperature": 1.5}}
+
+	s.SeverityOverrides = map[string]string{"seed_001": "critical"}
+	severities := make(map[string]string)
+	for _, finding := range s.ScanConfig(config) {
+		severities[finding.RuleID] = finding.Severity
+	}
+	if severities["SEED_001"] != "CRITICAL" {
+		t.Errorf("expected SEED_001 to be raised to CRITICAL, got %q", severities["SEED_001"])
+	}
+	if SeverityRank(severities["SEED_001"]) < SeverityRank("CRITICAL") {
+		t.Error("expected SEED_001 to meet a CRITICAL threshold")
+	}
+	if severities["TEMP_001"] != "HIGH" {
+		t.Errorf("expected TEMP_001 to keep HIGH, got %q", severities["TEMP_001"])
+	}
+}
+
+func TestScanner_RuleSetVersion(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeRules := func(name, version, id string) string {
+		path := filepath.Join(tmpDir, name)
+		content := `
+version: "` + version + `"
+rules:
+  - id: ` + id + `
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+compound:
+  - id: ` + id + `_COMBO
+    requires: [` + id + `]
+`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write rules file: %v", err)
+		}
+		return path
+	}
+
+	s, err := NewScannerFromFiles([]string{
+		writeRules("a.yaml", "2024.1", "PACK_A_001"),
+		writeRules("b.yaml", "2025.3", "PACK_B_001"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	want := map[string]string{
+		"PACK_A_001":       "2024.1",
+		"PACK_A_001_COMBO": "2024.1",
+		"PACK_B_001":       "2025.3",
+		"PACK_B_001_COMBO": "2025.3",
+	}
+	findings := s.ScanConfig(&Config{Data: map[string]interface{}{"temperature": 1.5}})
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findingIDs(findings))
+	}
+	for _, finding := range findings {
+		if finding.RuleSetVersion != want[finding.RuleID] {
+			t.Errorf("%s: RuleSetVersion = %q, want %q", finding.RuleID, finding.RuleSetVersion, want[finding.RuleID])
+		}
+	}
+}
+
+func TestNewScanner_InvalidChecks(t *testing.T) {
+	sub := []Check{{Type: "missing_field", Field: "seed"}}
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{name: "check and checks", rule: Rule{ID: "BAD_001", Check: Check{Type: "field_exists", Field: "seed"}, Checks: sub}},
+		{name: "unknown logic", rule: Rule{ID: "BAD_002", Checks: sub, Logic: "xor"}},
+		{name: "logic without checks", rule: Rule{ID: "BAD_003", Check: Check{Type: "field_exists", Field: "seed"}, Logic: "or"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newScanner(RulesFile{Rules: []Rule{tt.rule}}); err == nil || !strings.Contains(err.Error(), tt.rule.ID) {
+				t.Errorf("expected an error naming %s, got %v", tt.rule.ID, err)
+			}
+		})
+	}
+}
+
+func TestScanner_ParseErrorsAsFindings(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "TEMP_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	broken := filepath.Join(tmpDir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`{"temperature": 1.5,`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	if _, err := s.ScanFile(broken); err == nil {
+		t.Fatal("expected a parse error by default")
+	}
+
+	s.ParseErrorsAsFindings = true
+	s.SeverityOverrides = map[string]string{ParseErrorRuleID: "CRITICAL"}
+	result, err := s.ScanFile(broken)
+	if err != nil {
+		t.Fatalf("expected a result instead of an error, got %v", err)
+	}
+	if len(result.Findings) != 1 {
+		t.Fatalf("expected a single finding, got %v", findingIDs(result.Findings))
+	}
+	finding := result.Findings[0]
+	if finding.RuleID != ParseErrorRuleID || finding.Severity != "CRITICAL" || finding.Detail == "" {
+		t.Errorf("unexpected parse error finding %+v", finding)
+	}
+	if result.RiskScore != DefaultRiskWeights["CRITICAL"] {
+		t.Errorf("RiskScore = %d, want %d", result.RiskScore, DefaultRiskWeights["CRITICAL"])
+	}
+
+	// A missing file is still an error
+	if _, err := s.ScanFile(filepath.Join(tmpDir, "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestScanner_SortsFindings(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "KEY_LOW", Severity: "LOW", Fields: []string{"key"}, Check: Check{Type: "pattern_match", Patterns: []string{"sk-"}}},
+		{ID: "TOKEN", Severity: "HIGH", Fields: []string{"token"}, Check: Check{Type: "pattern_match", Patterns: []string{"sk-"}}},
+		{ID: "KEY_HIGH", Severity: "HIGH", Fields: []string{"key"}, Check: Check{Type: "pattern_match", Patterns: []string{"sk-"}}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	content := `{"zeta": {"key": "sk-1"}, "alpha": {"key": "sk-2", "token": "sk-3"}, "mid": {"token": "sk-4"}}`
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	var orders [][]string
+	for i := 0; i < 2; i++ {
+		result, err := s.ScanFile(configFile)
+		if err != nil {
+			t.Fatalf("scan failed: %v", err)
+		}
+		var order []string
+		for _, finding := range result.Findings {
+			order = append(order, finding.Severity+" "+finding.RuleID+" "+finding.Path)
+		}
+		orders = append(orders, order)
+	}
+
+	want := []string{
+		"HIGH KEY_HIGH $.alpha.key",
+		"HIGH KEY_HIGH $.zeta.key",
+		"HIGH TOKEN $.alpha.token",
+		"HIGH TOKEN $.mid.token",
+		"LOW KEY_LOW $.alpha.key",
+		"LOW KEY_LOW $.zeta.key",
+	}
+	for i, order := range orders {
+		if !reflect.DeepEqual(order, want) {
+			t.Errorf("scan %d order = %v, want %v", i+1, order, want)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	rules := RulesFile{
+		Version: "2.0",
+		Templates: []Rule{
+			{ID: "BASE_TEMP", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+		},
+		Rules: []Rule{
+			{ID: "TEMP_001", Extends: "BASE_TEMP"},
+			{ID: "KEY_001", Severity: "CRITICAL", Fields: []string{"api_key"}, Check: Check{Type: "pattern_match", Patterns: []string{"sk-[a-z]{10,}"}}},
+		},
+	}
+	config := &Config{Data: map[string]interface{}{"temperature": 1.5, "api_key": "sk-abcdefghijklmnop"}}
+
+	findings, err := Scan(rules, config)
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if ids := findingIDs(findings); !reflect.DeepEqual(ids, []string{"KEY_001", "TEMP_001"}) {
+		t.Errorf("findings = %v, want KEY_001 then TEMP_001", ids)
+	}
+	if findings[1].Severity != "HIGH" || findings[1].RuleSetVersion != "2.0" {
+		t.Errorf("expected the extended rule to inherit its severity and version, got %+v", findings[1])
+	}
+	if rules.Rules[0].Check.Type != "" || rules.Rules[0].RuleSetVersion != "" {
+		t.Errorf("expected Scan to leave the caller's rules unchanged, got %+v", rules.Rules[0])
+	}
+
+	if _, err := Scan(RulesFile{Rules: []Rule{{ID: "BAD", Extends: "MISSING"}}}, config); err == nil {
+		t.Error("expected an error for an unknown base rule")
+	}
+}
+
+func TestScanWithRules(t *testing.T) {
+	rulesYAML := []byte(`
+version: "1.0.0"
+rules:
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`)
+
+	tests := []struct {
+		name    string
+		data    string
+		format  string
+		want    []string
+		wantErr bool
+	}{
+		{name: "json", data: `{"temperature": 1.5}`, format: "json", want: []string{"TEMP_001"}},
+		{name: "yaml with dot", data: "temperature: 0.5\n", format: ".yaml", want: []string{}},
+		{name: "toml", data: "temperature = 1.9\n", format: "TOML", want: []string{"TEMP_001"}},
+		{name: "yml", data: "temperature: 1.2\n", format: "yml", want: []string{"TEMP_001"}},
+		{name: "unknown format", data: "temperature: 1.5", format: "xml", wantErr: true},
+		{name: "invalid config", data: `{"temperature": `, format: "json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := ScanWithRules(rulesYAML, []byte(tt.data), tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ScanWithRules failed: %v", err)
+			}
+			if ids := findingIDs(findings); !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("findings = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	if _, err := ScanWithRules([]byte("rules: ["), []byte(`{}`), "json"); err == nil {
+		t.Error("expected an error for invalid rules YAML")
+	}
+}
+
+func TestScanner_ResolveLines(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "TEMP_001", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+		{ID: "KEY_001", Severity: "CRITICAL", Fields: []string{"api_key"}, Check: Check{Type: "pattern_match", Patterns: []string{"sk-[a-z]{10,}"}}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "model: gpt-4\nsampling:\n  temperature: 1.9\nproviders:\n  openai:\n    api_key: sk-abcdefghijklmnop\n",
+		"config.json": "{\n  \"temperature\": 1.5,\n  \"api_key\": \"sk-abcdefghijklmnop\"\n}\n",
+		".env":        "# credentials\nMODEL=gpt-4\nexport api_key=sk-abcdefghijklmnop\n",
+	}
+	want := map[string]map[string]int{
+		"config.yaml": {"TEMP_001": 3, "KEY_001": 6},
+		"config.json": {"TEMP_001": 2, "KEY_001": 3},
+		".env":        {"KEY_001": 3},
+	}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+
+		s.ResolveLines = false
+		result, err := s.ScanFile(path)
+		if err != nil {
+			t.Fatalf("scan of %s failed: %v", name, err)
+		}
+		for _, finding := range result.Findings {
+			if finding.Line != 0 {
+				t.Errorf("%s: expected no line for %s by default, got %d", name, finding.RuleID, finding.Line)
+			}
+		}
+
+		s.ResolveLines = true
+		result, err = s.ScanFile(path)
+		if err != nil {
+			t.Fatalf("scan of %s failed: %v", name, err)
+		}
+		got := make(map[string]int)
+		for _, finding := range result.Findings {
+			got[finding.RuleID] = finding.Line
+		}
+		if !reflect.DeepEqual(got, want[name]) {
+			t.Errorf("%s: lines = %v, want %v", name, got, want[name])
+		}
+	}
+}
+
+func TestScanner_ScanComments(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "TEMP_001", Severity: "HIGH", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1.0}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	live := "sk-proj-abcdefghijklmnopqrstuvwx"
+	commented := "sk-ant-REDACTED"
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "model: gpt-4\n# api_key: " + commented + "\napi_key: " + live + "\n# queue: task-scheduler-default-worker-pool\n",
+		".env":        "MODEL=gpt-4\nAPI_KEY=" + live + "\n\n#ANTHROPIC_API_KEY=" + commented + "\n",
+		"config.toml": "model = \"gpt-4\" # old key " + commented + "\n",
+	}
+	want := map[string]int{"config.yaml": 2, ".env": 4, "config.toml": 1}
+
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+
+		s.ScanComments = false
+		result, err := s.ScanFile(path)
+		if err != nil {
+			t.Fatalf("scan of %s failed: %v", name, err)
+		}
+		if len(result.Findings) != 0 {
+			t.Errorf("%s: expected no findings without ScanComments, got %v", name, findingIDs(result.Findings))
+		}
+
+		s.ScanComments = true
+		result, err = s.ScanFile(path)
+		if err != nil {
+			t.Fatalf("scan of %s failed: %v", name, err)
+		}
+		if len(result.Findings) != 1 {
+			t.Fatalf("%s: expected only the commented key, got %+v", name, result.Findings)
+		}
+		finding := result.Findings[0]
+		if finding.RuleID != CommentedSecretRuleID || finding.Line != want[name] || finding.Detail != "Anthropic API key" {
+			t.Errorf("%s: got %s at line %d (%s), want %s at line %d", name, finding.RuleID, finding.Line, finding.Detail, CommentedSecretRuleID, want[name])
+		}
+		if strings.Contains(finding.Evidence, commented) {
+			t.Errorf("%s: evidence is not redacted: %q", name, finding.Evidence)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package scanner
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ConditionOperators lists the operators accepted in combined_conditions
+var ConditionOperators = []string{
+	"equals", "not_equals",
+	"greater_than", "greater_than_or_equal",
+	"less_than", "less_than_or_equal",
+	"contains", "exists", "not_exists",
+}
+
+// CheckTypes returns the names of every registered check type, including
+// custom checks added with RegisterCheck, in sorted order
+func CheckTypes() []string {
+	checksMu.RLock()
+	defer checksMu.RUnlock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemaEnums constrains string properties to their accepted values, keyed
+// by "Struct.yaml_key"
+func schemaEnums() map[string][]string {
+	flags := make([]string, 0, len(patternFlags))
+	for flag := range patternFlags {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+
+	return map[string][]string{
+		"Rule.severity":         Severities,
+		"Rule.logic":            {"and", "or"},
+		"SeverityOverride.from": Severities,
+		"SeverityOverride.to":   Severities,
+		"CompoundRule.severity": Severities,
+		"Check.type":            CheckTypes(),
+		"Check.condition":       {"any", "all", "any_value_exceeds"},
+		"Check.require":         {"all", "any", "both", "at_least_two", "at_least_n", "exactly_one", "none"},
+		"Check.expected_type":   {"string", "number", "boolean", "array", "object", "null"},
+		"Check.flags":           flags,
+		"Condition.operator":    ConditionOperators,
+	}
+}
+
+// RulesSchema returns a JSON Schema describing a rules file. It is derived
+// from the RulesFile structs and their yaml tags, with check types, operators
+// and severities enumerated.
+func RulesSchema() map[string]interface{} {
+	defs := make(map[string]interface{})
+	schemaFor(reflect.TypeOf(RulesFile{}), defs, schemaEnums())
+	root := defs["RulesFile"].(map[string]interface{})
+	delete(defs, "RulesFile")
+	// Rules files may carry documentation-only top-level sections, such as
+	// the severities table in the bundled rules
+	delete(root, "additionalProperties")
+
+	schema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "paramguard rules file",
+		"$defs":   defs,
+	}
+	for key, value := range root {
+		schema[key] = value
+	}
+	return schema
+}
+
+// schemaFor builds the schema for t. Structs are recorded in defs by type
+// name and referenced, so shared types like Rule appear once.
+func schemaFor(t reflect.Type, defs map[string]interface{}, enums map[string][]string) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), defs, enums)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), defs, enums)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), defs, enums)}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := defs[name]; ok {
+			return map[string]interface{}{"$ref": "#/$defs/" + name}
+		}
+		// Reserve the name first so self-referencing types terminate
+		defs[name] = nil
+
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if key == "" || key == "-" {
+				continue
+			}
+			property := schemaFor(field.Type, defs, enums)
+			if values := enums[name+"."+key]; len(values) > 0 {
+				if items, ok := property["items"].(map[string]interface{}); ok {
+					items["enum"] = values
+				} else {
+					property["enum"] = values
+				}
+			}
+			properties[key] = property
+		}
+		defs[name] = map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	return map[string]interface{}{}
+}
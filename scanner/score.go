@@ -0,0 +1,34 @@
+package scanner
+
+import "strings"
+
+// MaxRiskScore caps a risk score
+const MaxRiskScore = 100
+
+// DefaultRiskWeights are the points each finding adds to a file's risk
+// score by severity
+var DefaultRiskWeights = map[string]int{
+	"CRITICAL": 40,
+	"HIGH":     20,
+	"MEDIUM":   8,
+	"LOW":      2,
+}
+
+// RiskScore sums the weight of each finding's severity, capped at
+// MaxRiskScore. Severities missing from weights fall back to
+// DefaultRiskWeights; a nil weights map uses the defaults.
+func RiskScore(findings []Finding, weights map[string]int) int {
+	score := 0
+	for _, finding := range findings {
+		severity := strings.ToUpper(finding.Severity)
+		weight, ok := weights[severity]
+		if !ok {
+			weight = DefaultRiskWeights[severity]
+		}
+		score += weight
+	}
+	if score > MaxRiskScore {
+		return MaxRiskScore
+	}
+	return score
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRiskScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities []string
+		weights    map[string]int
+		expected   int
+	}{
+		{name: "clean file", expected: 0},
+		{name: "critical and high", severities: []string{"CRITICAL", "HIGH"}, expected: 60},
+		{name: "every severity", severities: []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}, expected: 70},
+		{name: "capped", severities: []string{"CRITICAL", "CRITICAL", "CRITICAL"}, expected: 100},
+		{name: "lowercase severity", severities: []string{"medium"}, expected: 8},
+		{name: "custom weights", severities: []string{"CRITICAL", "LOW"}, weights: map[string]int{"CRITICAL": 50}, expected: 52},
+		{name: "zero weight", severities: []string{"LOW", "LOW"}, weights: map[string]int{"LOW": 0}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var findings []Finding
+			for _, severity := range tt.severities {
+				findings = append(findings, Finding{Severity: severity})
+			}
+			if got := RiskScore(findings, tt.weights); got != tt.expected {
+				t.Errorf("expected score %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestScanner_RiskScore(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	rulesContent := `
+version: "1.0.0"
+rules:
+  - id: TEST_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+  - id: TEST_002
+    name: "API Key Found"
+    severity: CRITICAL
+    check:
+      type: pattern_match
+      patterns:
+        - "sk-[a-zA-Z0-9]{10,}"
+    fields:
+      - api_key
+`
+	if err := os.WriteFile(rulesFile, []byte(rulesContent), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	risky := filepath.Join(tmpDir, "risky.json")
+	if err := os.WriteFile(risky, []byte(`{"temperature": 1.5, "api_key": "sk-abcdefghijklmnop"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	clean := filepath.Join(tmpDir, "clean.json")
+	if err := os.WriteFile(clean, []byte(`{"temperature": 0.5}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	result, err := s.ScanFile(risky)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RiskScore != 60 {
+		t.Errorf("expected risk score 60, got %d", result.RiskScore)
+	}
+
+	result, err = s.ScanFile(clean)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RiskScore != 0 {
+		t.Errorf("expected risk score 0 for a clean file, got %d", result.RiskScore)
+	}
+
+	s.RiskWeights = map[string]int{"HIGH": 5, "CRITICAL": 10}
+	result, err = s.ScanFile(risky)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.RiskScore != 15 {
+		t.Errorf("expected risk score 15 with custom weights, got %d", result.RiskScore)
+	}
+}
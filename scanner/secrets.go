@@ -0,0 +1,220 @@
+package scanner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// defaultSecretMinLength is the shortest token secret_scan reports as a
+// generic secret when min_length is unset
+const defaultSecretMinLength = 32
+
+// secretProvider is a key format bundled with secret_scan. When pattern has
+// a capture group, the group is the key and the rest of the match is context.
+type secretProvider struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// secretProviders lists the key formats secret_scan recognizes. The first
+// format that matches a value names it, so Anthropic's sk-ant- keys come
+// before OpenAI's broader sk- format. The sk- formats must start a word, so
+// names such as task-scheduler-default-worker-pool don't match; RE2 has no
+// lookbehind, so the preceding character is matched outside the key group.
+var secretProviders = []secretProvider{
+	{"Anthropic API key", regexp.MustCompile(`(?:^|[^A-Za-z0-9_-])(sk-ant-[A-Za-z0-9_-]{20,})`)},
+	{"OpenAI API key", regexp.MustCompile(`(?:^|[^A-Za-z0-9_-])(sk-(?:proj-|svcacct-|admin-)?[A-Za-z0-9_-]{20,})`)},
+	{"AWS access key ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"Google API key", regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`)},
+}
+
+// findAll returns the spans of every key in str
+func (p secretProvider) findAll(str string) [][]int {
+	var spans [][]int
+	for _, loc := range p.pattern.FindAllStringSubmatchIndex(str, -1) {
+		if len(loc) >= 4 {
+			loc = loc[2:4]
+		}
+		spans = append(spans, loc[:2])
+	}
+	return spans
+}
+
+// secretToken splits values into candidate tokens for the generic check.
+// URLs and dotted names break apart at "." and ":".
+var secretToken = regexp.MustCompile(`[A-Za-z0-9_+/=-]+`)
+
+// nameSeparator splits a token into the segments of a path, model ID, or
+// resource name
+var nameSeparator = regexp.MustCompile(`[/_-]+`)
+
+// wordSegment matches a segment that reads like part of a name: a word in
+// lower, upper, or title case with optional leading and trailing digits
+// (llama, Instruct, 70B, central1, 20240611). Random keys mix case within a
+// segment and don't match.
+var wordSegment = regexp.MustCompile(`^[0-9]*(?:[A-Z]?[a-z]+|[A-Z]+)?[0-9]*[A-Za-z]?$`)
+
+// isNameShaped reports whether token is a path, model ID, or resource name
+// such as meta-llama/Llama-3-70B-Instruct or projects/my-project-123: it has
+// separators and every segment between them is word-like
+func isNameShaped(token string) bool {
+	if !nameSeparator.MatchString(token) {
+		return false
+	}
+	for _, segment := range nameSeparator.Split(token, -1) {
+		if !wordSegment.MatchString(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSecretScan looks at every string value in the config, whatever its
+// key, and reports the first bundled provider key format it contains.
+// Values without a provider key are reported when they hold a token of at
+// least min_length characters (default 32) that mixes letters and digits
+// and has at least min_entropy bits per character (default 4.0); paths,
+// model IDs, and resource names made of word-like segments are not. Matches
+// that an allowlist regex also matches are skipped.
+func checkSecretScan(rule Rule, config *Config) []checkResult {
+	check := rule.Check
+	minEntropy := check.MinEntropy
+	if minEntropy == 0 {
+		minEntropy = defaultMinEntropy
+	}
+	minLength := check.MinLength
+	if minLength == 0 {
+		minLength = defaultSecretMinLength
+	}
+	allowlist := compileAllowlist(check)
+
+	var leaves []stringLeaf
+	collectStrings(config.Data, "", "$", &leaves)
+
+	var results []checkResult
+	for _, leaf := range leaves {
+		str := leaf.value.(string)
+		if len(str) > maxPatternInput {
+			str = str[:maxPatternInput]
+		}
+		match, detail := providerSecret(str, allowlist)
+		if match == "" {
+			match, detail = genericSecret(str, minEntropy, minLength, allowlist)
+		}
+		if match != "" {
+			results = append(results, checkResult{violated: true, location: leaf.location, path: leaf.path, evidence: evidenceFor(check, match), detail: detail})
+		}
+	}
+	return results
+}
+
+// providerSecret returns the first provider key in str and the provider's
+// name, or "" when there is none
+func providerSecret(str string, allowlist []*regexp.Regexp) (string, string) {
+	for _, provider := range secretProviders {
+		for _, loc := range provider.findAll(str) {
+			if match := str[loc[0]:loc[1]]; !matchesAny(match, allowlist) {
+				return match, provider.name
+			}
+		}
+	}
+	return "", ""
+}
+
+// genericSecret returns the first random-looking token in str with a
+// description of it, or "" when there is none
+func genericSecret(str string, minEntropy float64, minLength int, allowlist []*regexp.Regexp) (string, string) {
+	for _, token := range secretToken.FindAllString(str, -1) {
+		if len(token) < minLength || !hasLetterAndDigit(token) || isNameShaped(token) || matchesAny(token, allowlist) {
+			continue
+		}
+		if entropy := shannonEntropy(token); entropy >= minEntropy {
+			return token, fmt.Sprintf("high-entropy %d-character token (%.2f bits/char)", len(token), entropy)
+		}
+	}
+	return "", ""
+}
+
+func hasLetterAndDigit(s string) bool {
+	letter, digit := false, false
+	for _, r := range s {
+		letter = letter || unicode.IsLetter(r)
+		digit = digit || unicode.IsDigit(r)
+	}
+	return letter && digit
+}
+
+// commentedSecrets runs the bundled provider key formats over every line of
+// a local file's raw content and reports keys that are not part of any
+// parsed value, such as keys in comments that parsing drops. Each finding
+// carries the line it was found on.
+func (s *Scanner) commentedSecrets(filePath string, config *Config) []Finding {
+	if IsRemoteSource(filePath) {
+		return nil
+	}
+	data, _, err := readSource(filePath, s.ParseOptions)
+	if err != nil {
+		return nil
+	}
+
+	var leaves []stringLeaf
+	collectStrings(config.Data, "", "$", &leaves)
+	parsed := func(match string) bool {
+		for _, leaf := range leaves {
+			if strings.Contains(leaf.value.(string), match) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var findings []Finding
+	for i, line := range strings.Split(string(data), "\n") {
+		if len(line) > maxPatternInput {
+			line = line[:maxPatternInput]
+		}
+		// A key claimed by an earlier provider is not reported again by a
+		// broader one (sk-ant- keys also match the OpenAI format)
+		var claimed [][]int
+		for _, provider := range secretProviders {
+			for _, loc := range provider.findAll(line) {
+				match := line[loc[0]:loc[1]]
+				if overlaps(loc, claimed) {
+					continue
+				}
+				claimed = append(claimed, loc)
+				if parsed(match) {
+					continue
+				}
+				finding := Finding{
+					RuleID:         CommentedSecretRuleID,
+					Name:           "Secret in Commented-Out Content",
+					Severity:       "CRITICAL",
+					Category:       "secrets",
+					Description:    "The file contains an API key outside any parsed setting, typically in a comment. It is still readable by anyone with the file and stays in version control history.",
+					Location:       "unparsed content",
+					Line:           i + 1,
+					Evidence:       RedactValue(match),
+					Detail:         provider.name,
+					Recommendation: "Delete the line instead of commenting it out, and rotate the key since it has been exposed.",
+					References:     []string{},
+				}
+				s.overrideSeverity(&finding)
+				findings = append(findings, finding)
+			}
+		}
+	}
+	return findings
+}
+
+// overlaps reports whether the span loc shares any byte with a span in spans
+func overlaps(loc []int, spans [][]int) bool {
+	for _, span := range spans {
+		if loc[0] < span[1] && span[0] < loc[1] {
+			return true
+		}
+	}
+	return false
+}
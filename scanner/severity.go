@@ -0,0 +1,28 @@
+package scanner
+
+import "strings"
+
+// Severities lists the supported severity levels from most to least severe
+var Severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW"}
+
+// SeverityRank orders severities so that higher is more severe. Unknown
+// severities rank 0.
+func SeverityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return 4
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// IsValidSeverity reports whether severity is one of the supported levels
+func IsValidSeverity(severity string) bool {
+	return SeverityRank(severity) > 0
+}
@@ -0,0 +1,18 @@
+package scanner
+
+import "testing"
+
+func TestSeverityRank(t *testing.T) {
+	for i := 1; i < len(Severities); i++ {
+		if SeverityRank(Severities[i-1]) <= SeverityRank(Severities[i]) {
+			t.Errorf("%s should rank above %s", Severities[i-1], Severities[i])
+		}
+	}
+
+	if SeverityRank("high") != SeverityRank("HIGH") {
+		t.Error("SeverityRank should be case-insensitive")
+	}
+	if IsValidSeverity("URGENT") {
+		t.Error("URGENT should not be a valid severity")
+	}
+}
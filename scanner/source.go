@@ -0,0 +1,193 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultFetchTimeout bounds fetching a remote config when
+// ParseOptions.FetchTimeout is zero
+const DefaultFetchTimeout = 30 * time.Second
+
+// IsRemoteSource reports whether source is an http:// or https:// URL
+func IsRemoteSource(source string) bool {
+	lower := strings.ToLower(source)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// readSource loads a config from a local path or URL, decompressing gzip
+// data. It returns the contents and the file extension that decides the
+// format: the name's extension without .gz, or for URLs without a known
+// extension, one derived from the Content-Type.
+func readSource(source string, opts ParseOptions) ([]byte, string, error) {
+	maxSize := opts.MaxFileSize
+	if maxSize == 0 {
+		maxSize = DefaultMaxFileSize
+	}
+
+	var data []byte
+	var ext string
+	var err error
+	if IsRemoteSource(source) {
+		data, ext, err = fetchSource(source, opts.FetchTimeout, maxSize)
+	} else {
+		data, err = readLocalSource(source, maxSize)
+		ext = strings.ToLower(filepath.Ext(source))
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	compressed := ext == ".gz"
+	if compressed {
+		name := strings.TrimSuffix(source, filepath.Ext(source))
+		if IsRemoteSource(source) {
+			if u, err := url.Parse(source); err == nil {
+				name = strings.TrimSuffix(u.Path, path.Ext(u.Path))
+			}
+		}
+		ext = strings.ToLower(path.Ext(name))
+	}
+	if compressed || isGzip(data) {
+		if data, err = gunzip(data, maxSize); err != nil {
+			return nil, "", err
+		}
+	}
+	return data, ext, nil
+}
+
+func readLocalSource(filePath string, maxSize int64) ([]byte, error) {
+	if maxSize > 0 {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		if info.Size() > maxSize {
+			return nil, fmt.Errorf("file is %d bytes, exceeding the %d byte limit", info.Size(), maxSize)
+		}
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// fetchSource downloads a remote config, refusing bodies over maxSize
+func fetchSource(source string, timeout time.Duration, maxSize int64) ([]byte, string, error) {
+	if timeout == 0 {
+		timeout = DefaultFetchTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch config: %s", resp.Status)
+	}
+
+	data, err := readLimited(resp.Body, maxSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	ext := ""
+	if u, err := url.Parse(source); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	if !isKnownExtension(ext) {
+		if fromType := extForContentType(resp.Header.Get("Content-Type")); fromType != "" {
+			ext = fromType
+		}
+	}
+	return data, ext, nil
+}
+
+// readLimited reads r to the end, failing once more than maxSize bytes have
+// been read. A non-positive maxSize reads without a limit.
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("content exceeds the %d byte limit", maxSize)
+	}
+	return data, nil
+}
+
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+// gunzip decompresses data, applying the size limit to the decompressed
+// output so a small archive cannot expand without bound
+func gunzip(data []byte, maxSize int64) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer reader.Close()
+
+	decompressed, err := readLimited(reader, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	return decompressed, nil
+}
+
+// IsConfigFile reports whether path has an extension paramguard parses,
+// optionally followed by .gz
+func IsConfigFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".gz" {
+		ext = strings.ToLower(filepath.Ext(strings.TrimSuffix(path, filepath.Ext(path))))
+	}
+	return ext != ".gz" && isKnownExtension(ext)
+}
+
+func isKnownExtension(ext string) bool {
+	switch ext {
+	case ".json", ".jsonc", ".json5", ".yaml", ".yml", ".toml", ".hcl", ".tf",
+		".env", ".properties", ".ini", ".cfg", ".conf", ".gz":
+		return true
+	}
+	return false
+}
+
+// extForContentType maps a response media type to the extension of the
+// matching parser, or "" when it is not recognized
+func extForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	switch mediaType {
+	case "application/json":
+		return ".json"
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return ".yaml"
+	case "application/toml", "text/toml":
+		return ".toml"
+	case "application/gzip", "application/x-gzip":
+		return ".gz"
+	}
+	return ""
+}
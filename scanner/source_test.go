@@ -0,0 +1,105 @@
+package scanner
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseConfigFile_Remote(t *testing.T) {
+	compressed := gzipBytes(t, `{"temperature": 1.7}`)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"temperature": 1.5}`))
+	})
+	mux.HandleFunc("/config.json.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(compressed)
+	})
+	mux.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write([]byte("temperature: 1.9\nmodel: gpt-4o\n"))
+	})
+	mux.HandleFunc("/slow.json", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte(`{}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		path string
+		want float64
+	}{
+		{"json by extension", "/config.json", 1.5},
+		{"gzipped json", "/config.json.gz", 1.7},
+		{"yaml by content type", "/settings", 1.9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := ParseConfigFile(server.URL + tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if val, ok := config.GetValue("temperature"); !ok || val != tt.want {
+				t.Errorf("temperature = %v, %v; want %v", val, ok, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseConfigFile(server.URL + "/missing.json"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("expected a 404 error, got %v", err)
+	}
+	if _, err := ParseConfigFileWithOptions(server.URL+"/slow.json", ParseOptions{FetchTimeout: 50 * time.Millisecond}); err == nil {
+		t.Error("expected the fetch to time out")
+	}
+	if _, err := ParseConfigFileWithOptions(server.URL+"/config.json", ParseOptions{MaxFileSize: 5}); err == nil {
+		t.Error("expected the size limit to apply to remote configs")
+	}
+}
+
+func TestParseConfigFile_Gzip(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "config.yaml.gz")
+	if err := os.WriteFile(filePath, gzipBytes(t, "temperature: 1.4\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+
+	config, err := ParseConfigFile(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if val, ok := config.GetValue("temperature"); !ok || val != 1.4 {
+		t.Errorf("temperature = %v, %v; want 1.4", val, ok)
+	}
+
+	// The size limit applies to the decompressed content
+	large := gzipBytes(t, "padding: "+strings.Repeat("x", 4096)+"\n")
+	if err := os.WriteFile(filePath, large, 0644); err != nil {
+		t.Fatalf("failed to write test file: %v", err)
+	}
+	if _, err := ParseConfigFileWithOptions(filePath, ParseOptions{MaxFileSize: 1024}); err == nil {
+		t.Error("expected the decompressed size to exceed the limit")
+	}
+}
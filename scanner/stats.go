@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RuleStat is the time spent evaluating one rule across all scanned files
+type RuleStat struct {
+	RuleID      string        `json:"rule_id"`
+	Evaluations int           `json:"evaluations"`
+	Total       time.Duration `json:"total_ns"`
+}
+
+// FileStat is the time spent parsing and scanning one file
+type FileStat struct {
+	File     string        `json:"file"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// ScanStats aggregates timings collected while Scanner.CollectStats is set.
+// Rules are ordered by total time and files by duration, slowest first.
+type ScanStats struct {
+	Rules []RuleStat `json:"rules"`
+	Files []FileStat `json:"files"`
+}
+
+type statsCollector struct {
+	mu    sync.Mutex
+	rules map[string]*RuleStat
+	files []FileStat
+}
+
+func (c *statsCollector) recordRule(ruleID string, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rules == nil {
+		c.rules = make(map[string]*RuleStat)
+	}
+	stat, ok := c.rules[ruleID]
+	if !ok {
+		stat = &RuleStat{RuleID: ruleID}
+		c.rules[ruleID] = stat
+	}
+	stat.Evaluations++
+	stat.Total += elapsed
+}
+
+func (c *statsCollector) recordFile(file string, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.files = append(c.files, FileStat{File: file, Duration: elapsed})
+}
+
+func (c *statsCollector) snapshot() ScanStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := ScanStats{
+		Rules: make([]RuleStat, 0, len(c.rules)),
+		Files: make([]FileStat, len(c.files)),
+	}
+	for _, stat := range c.rules {
+		stats.Rules = append(stats.Rules, *stat)
+	}
+	copy(stats.Files, c.files)
+
+	sort.SliceStable(stats.Rules, func(i, j int) bool {
+		if stats.Rules[i].Total != stats.Rules[j].Total {
+			return stats.Rules[i].Total > stats.Rules[j].Total
+		}
+		return stats.Rules[i].RuleID < stats.Rules[j].RuleID
+	})
+	sort.SliceStable(stats.Files, func(i, j int) bool {
+		return stats.Files[i].Duration > stats.Files[j].Duration
+	})
+	return stats
+}
+
+// Stats returns the timings gathered so far. It is empty unless
+// CollectStats was set before scanning.
+func (s *Scanner) Stats() ScanStats {
+	return s.stats.snapshot()
+}
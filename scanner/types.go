@@ -2,9 +2,57 @@ package scanner
 
 // RulesFile represents the structure of rules.yaml
 type RulesFile struct {
-	Version    string   `yaml:"version"`
-	Rules      []Rule   `yaml:"rules"`
-	Categories []string `yaml:"categories"`
+	Version    string    `yaml:"version"`
+	Rules      []Rule    `yaml:"rules"`
+	Categories []string  `yaml:"categories"`
+	Profiles   []Profile `yaml:"profiles,omitempty"`
+
+	// Templates are rule-shaped blocks that rules can extend but that are
+	// never run themselves
+	Templates []Rule `yaml:"templates,omitempty"`
+
+	// Compound rules report one extra finding when all of their trigger
+	// rules fire on the same file
+	Compound []CompoundRule `yaml:"compound,omitempty"`
+
+	// ContextWindows adds to or overrides DefaultContextWindows for every
+	// context_window_exceeded check in the file
+	ContextWindows map[string]int `yaml:"context_windows,omitempty"`
+}
+
+// CompoundRule emits a synthetic finding when every rule listed in Requires
+// has a finding in the same file. Severity defaults to CRITICAL.
+type CompoundRule struct {
+	ID             string   `yaml:"id"`
+	Name           string   `yaml:"name"`
+	Severity       string   `yaml:"severity,omitempty"`
+	Category       string   `yaml:"category,omitempty"`
+	Description    string   `yaml:"description"`
+	Requires       []string `yaml:"requires"`
+	Recommendation string   `yaml:"recommendation"`
+	References     []string `yaml:"references,omitempty"`
+
+	// RuleSetVersion is the version of the rules file that defined the rule
+	RuleSetVersion string `yaml:"-"`
+}
+
+// Profile adjusts which rules run, and at what severity, for config files
+// whose name matches one of its globs. The first matching profile applies.
+type Profile struct {
+	Name     string             `yaml:"name"`
+	Files    []string           `yaml:"files"`
+	Rules    []string           `yaml:"rules,omitempty"`
+	Disable  []string           `yaml:"disable,omitempty"`
+	Severity []SeverityOverride `yaml:"severity,omitempty"`
+}
+
+// SeverityOverride changes the severity of findings from the listed rules
+// or category. From, when set, limits it to findings at that severity.
+type SeverityOverride struct {
+	Rules    []string `yaml:"rules,omitempty"`
+	Category string   `yaml:"category,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       string   `yaml:"to"`
 }
 
 // Rule represents a single security rule
@@ -18,16 +66,106 @@ type Rule struct {
 	Recommendation string   `yaml:"recommendation"`
 	References     []string `yaml:"references"`
 	Fields         []string `yaml:"fields,omitempty"`
+	Enabled        *bool    `yaml:"enabled,omitempty"`
+	Tags           []string `yaml:"tags,omitempty"`
+	CWE            string   `yaml:"cwe,omitempty"`
+	Extends        string   `yaml:"extends,omitempty"`
+
+	// RecommendationTemplate, when set, is rendered with text/template for
+	// each finding in place of Recommendation (see RecommendationData)
+	RecommendationTemplate string `yaml:"recommendation_template,omitempty"`
+
+	// AppliesWhen gates the rule: it is only evaluated for configs where
+	// every condition holds
+	AppliesWhen []Condition `yaml:"applies_when,omitempty"`
+
+	// Checks, when set, is used instead of Check: Logic "and" (the default)
+	// fires when every check fires and "or" when any does, with a single
+	// finding either way
+	Checks []Check `yaml:"checks,omitempty"`
+	Logic  string  `yaml:"logic,omitempty"`
+
+	// MaxFindings caps the findings the rule reports for one file; a
+	// TruncatedRuleID finding says how many were dropped. Zero means no
+	// limit.
+	MaxFindings int `yaml:"max_findings,omitempty"`
+
+	// RuleSetVersion is the version of the rules file that defined the rule
+	RuleSetVersion string `yaml:"-"`
 }
 
-// Check represents the detection logic
+// IsEnabled reports whether the rule should run. Rules are enabled unless
+// they explicitly set enabled: false.
+func (r Rule) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
+}
+
+// AppliesTo reports whether every applies_when condition holds for config.
+// Rules without conditions apply to every config.
+func (r Rule) AppliesTo(config *Config) bool {
+	config = ruleConfig(r, config)
+	for _, condition := range r.AppliesWhen {
+		if !checkCondition(condition, config) {
+			return false
+		}
+	}
+	return true
+}
+
+// checksAbsence reports whether the rule can fire because a config lacks a
+// field: a missing field check, a combined check that looks for absent
+// fields or for no conditions met, or an applies_when that requires a field
+// to be absent
+func (r Rule) checksAbsence() bool {
+	checks := r.Checks
+	if len(checks) == 0 {
+		checks = []Check{r.Check}
+	}
+	for _, check := range checks {
+		switch check.Type {
+		case "missing_field", "missing_fields", "conditional_missing":
+			return true
+		case "combined_conditions":
+			if check.Require == "none" || hasNotExists(check.Conditions) {
+				return true
+			}
+		}
+	}
+	return hasNotExists(r.AppliesWhen)
+}
+
+func hasNotExists(conditions []Condition) bool {
+	for _, condition := range conditions {
+		if condition.Operator == "not_exists" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag reports whether the rule carries the given tag
+func (r Rule) HasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Check represents the detection logic. Field and Fields are
+// interchangeable in checks that take field names; a Scanner merges Field
+// into Fields when it loads the rules.
 type Check struct {
 	Type         string        `yaml:"type"`
 	Parameter    string        `yaml:"parameter,omitempty"`
 	Parameters   []string      `yaml:"parameters,omitempty"`
 	Field        string        `yaml:"field,omitempty"`
+	Path         string        `yaml:"path,omitempty"`
 	Fields       []string      `yaml:"fields,omitempty"`
 	Patterns     []string      `yaml:"patterns,omitempty"`
+	Flags        []string      `yaml:"flags,omitempty"`
+	Anchored     bool          `yaml:"anchored,omitempty"`
 	Operator     string        `yaml:"operator,omitempty"`
 	Value        interface{}   `yaml:"value,omitempty"`
 	Min          float64       `yaml:"min,omitempty"`
@@ -35,11 +173,38 @@ type Check struct {
 	Condition    string        `yaml:"condition,omitempty"`
 	Conditions   []Condition   `yaml:"conditions,omitempty"`
 	Require      string        `yaml:"require,omitempty"`
+	RequireCount int           `yaml:"require_count,omitempty"`
 	HasAny       []string      `yaml:"has_any,omitempty"`
 	MissingAll   []string      `yaml:"missing_all,omitempty"`
 	Values       []interface{} `yaml:"values,omitempty"`
 	MaxSequences int           `yaml:"max_sequences,omitempty"`
 	MaxLength    int           `yaml:"max_length,omitempty"`
+	ExpectedType string        `yaml:"expected_type,omitempty"`
+	Redact       *bool         `yaml:"redact,omitempty"`
+	MinCount     int           `yaml:"min_count,omitempty"`
+	MaxCount     int           `yaml:"max_count,omitempty"`
+	ReplacedBy   string        `yaml:"replaced_by,omitempty"`
+	Allow        []string      `yaml:"allow,omitempty"`
+	Allowlist    []string      `yaml:"allowlist,omitempty"`
+	MinEntropy   float64       `yaml:"min_entropy,omitempty"`
+	MinLength    int           `yaml:"min_length,omitempty"`
+
+	CaseInsensitive bool `yaml:"case_insensitive,omitempty"`
+
+	// AllowedSchemes lists the URL schemes url_scheme accepts (default
+	// https); BlockPrivateHosts also flags localhost and private addresses
+	AllowedSchemes    []string `yaml:"allowed_schemes,omitempty"`
+	BlockPrivateHosts bool     `yaml:"block_private_hosts,omitempty"`
+
+	// Negate makes the rule fire when the check passes and stay quiet when
+	// it would have fired
+	Negate bool `yaml:"negate,omitempty"`
+
+	// ContextWindows adds to or overrides DefaultContextWindows for
+	// context_window_exceeded; DefaultContextWindow applies to models in
+	// neither table, which are skipped when it is zero
+	ContextWindows       map[string]int `yaml:"context_windows,omitempty"`
+	DefaultContextWindow int            `yaml:"default_context_window,omitempty"`
 }
 
 // Condition for combined checks
@@ -51,8 +216,13 @@ type Condition struct {
 
 // ScanResult represents the result of scanning a file
 type ScanResult struct {
-	File     string    `json:"file"`
-	Findings []Finding `json:"findings"`
+	File         string    `json:"file"`
+	AbsolutePath string    `json:"absolute_path,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	Findings     []Finding `json:"findings"`
+	RiskScore    int       `json:"risk_score"`
+	Passed       []string  `json:"passed,omitempty"`
+	Error        string    `json:"error,omitempty"`
 }
 
 // Finding represents a security issue found
@@ -61,14 +231,41 @@ type Finding struct {
 	Name           string   `json:"name"`
 	Severity       string   `json:"severity"`
 	Category       string   `json:"category"`
+	CWE            string   `json:"cwe,omitempty"`
 	Description    string   `json:"description"`
 	Location       string   `json:"location,omitempty"`
+	Path           string   `json:"path,omitempty"`
+	Pointer        string   `json:"pointer,omitempty"`
+	Line           int      `json:"line,omitempty"`
+	Evidence       string   `json:"evidence,omitempty"`
+	Detail         string   `json:"detail,omitempty"`
 	Recommendation string   `json:"recommendation"`
 	References     []string `json:"references"`
+	RuleSetVersion string   `json:"rule_set_version,omitempty"`
+
+	// FingerprintID identifies the finding across runs. ScanFile sets it to
+	// Fingerprint of the scanned file, before any output redaction.
+	FingerprintID string `json:"fingerprint,omitempty"`
+}
+
+// Fix describes a value changed by ApplyFixes
+type Fix struct {
+	RuleID   string      `json:"rule_id"`
+	Location string      `json:"location"`
+	Old      interface{} `json:"old"`
+	New      interface{} `json:"new"`
 }
 
 // Config represents a parsed configuration
 type Config struct {
 	Data     map[string]interface{}
 	FilePath string
+
+	// DuplicateKeys lists dotted paths of keys defined more than once.
+	// Only populated when parsing with ParseOptions.StrictJSON.
+	DuplicateKeys []string
+
+	// CaseInsensitive makes HasField, GetAllFieldValues, and GetValue ignore
+	// case and "_"/"-" separators when comparing field names
+	CaseInsensitive bool
 }
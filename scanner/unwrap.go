@@ -0,0 +1,252 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// embeddedConfig is a config found in the string values of another config,
+// such as the data block of a Kubernetes ConfigMap
+type embeddedConfig struct {
+	location string
+	path     string
+	config   *Config
+	// fragment marks values that are only part of a config, which are not
+	// checked for missing fields
+	fragment bool
+}
+
+// wrapperKey is a top-level key whose string values may hold configs
+type wrapperKey struct {
+	name   string
+	base64 bool
+}
+
+// wrapperKeys returns the keys to unwrap in data: unwrapKey when set,
+// otherwise data (and stringData) for Kubernetes ConfigMap and Secret
+// manifests. Secret data values are base64-encoded.
+func wrapperKeys(data map[string]interface{}, unwrapKey string) []wrapperKey {
+	if unwrapKey != "" {
+		return []wrapperKey{{name: unwrapKey}}
+	}
+	if _, ok := data["apiVersion"]; !ok {
+		return nil
+	}
+	switch data["kind"] {
+	case "ConfigMap":
+		return []wrapperKey{{name: "data"}}
+	case "Secret":
+		return []wrapperKey{{name: "data", base64: true}, {name: "stringData"}}
+	}
+	return nil
+}
+
+// unwrapConfig returns the configs embedded in config and whether config is
+// a wrapper at all. Each string value under a wrapper key that is named like
+// a config file (config.yaml) or holds a JSON, YAML, or TOML object is parsed
+// as its own config; embedded Kubernetes manifests are unwrapped in turn.
+// The remaining values under the key are returned together as one config,
+// which is a fragment when configs were found beside it.
+func unwrapConfig(config *Config, opts ParseOptions) ([]embeddedConfig, bool, error) {
+	var embedded []embeddedConfig
+	unwrapped := false
+
+	for _, wrapper := range wrapperKeys(config.Data, opts.UnwrapKey) {
+		block, ok := config.Data[wrapper.name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		unwrapped = true
+		blockPath := jsonPathKey("$", wrapper.name)
+		rest := make(map[string]interface{})
+		found := len(embedded)
+
+		for _, key := range sortedKeys(block) {
+			content, ok := block[key].(string)
+			if !ok {
+				rest[key] = block[key]
+				continue
+			}
+			if wrapper.base64 {
+				if decoded, err := base64.StdEncoding.DecodeString(content); err == nil {
+					content = string(decoded)
+				}
+			}
+
+			location := wrapper.name + "." + key
+			data, duplicateKeys, err := parseEmbedded(key, content, opts)
+			if err != nil {
+				return nil, true, fmt.Errorf("%s: %w", location, err)
+			}
+			if data == nil {
+				rest[key] = content
+				continue
+			}
+
+			inner := &Config{Data: data, FilePath: config.FilePath, DuplicateKeys: duplicateKeys}
+			if opts.NormalizeValues {
+				NormalizeConfig(inner)
+			}
+			valuePath := jsonPathKey(blockPath, key)
+
+			// Only Kubernetes manifests are unwrapped again; UnwrapKey
+			// applies to the outer document
+			nested, isWrapper, err := unwrapConfig(inner, ParseOptions{NormalizeValues: opts.NormalizeValues})
+			if err != nil {
+				return nil, true, fmt.Errorf("%s: %w", location, err)
+			}
+			if !isWrapper {
+				embedded = append(embedded, embeddedConfig{location: location, path: valuePath, config: inner})
+				continue
+			}
+			for _, n := range nested {
+				embedded = append(embedded, embeddedConfig{
+					location: location + ": " + n.location,
+					path:     valuePath + strings.TrimPrefix(n.path, "$"),
+					config:   n.config,
+				})
+			}
+		}
+
+		if len(rest) > 0 {
+			restConfig := &Config{Data: rest, FilePath: config.FilePath}
+			if opts.NormalizeValues {
+				NormalizeConfig(restConfig)
+			}
+			embedded = append(embedded, embeddedConfig{location: wrapper.name, path: blockPath, config: restConfig, fragment: len(embedded) > found})
+		}
+	}
+
+	return embedded, unwrapped, nil
+}
+
+// parseEmbedded parses a string value as a config. Values under a key with
+// a config extension must parse; other values are parsed only if they hold
+// a JSON, YAML, or TOML object, and nil is returned when they don't.
+func parseEmbedded(key, content string, opts ParseOptions) (map[string]interface{}, []string, error) {
+	ext := strings.ToLower(path.Ext(key))
+	if ext != ".gz" && isKnownExtension(ext) {
+		data, duplicateKeys, err := parseData([]byte(content), ext, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		return data, duplicateKeys, nil
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return nil, nil, nil
+	}
+	data, err := autoDetectFormat([]byte(content))
+	if err != nil || data == nil {
+		return nil, nil, nil
+	}
+	return data, nil, nil
+}
+
+// maxBase64Depth bounds how many times configs decoded from base64 values
+// are searched for further encoded configs
+const maxBase64Depth = 3
+
+// minBase64Length is the shortest value ParseOptions.DecodeBase64Values
+// tries to decode; shorter strings are too often plain words that happen to
+// be valid base64
+const minBase64Length = 16
+
+var base64Value = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+
+// decodeBase64Configs returns the configs hidden in base64-encoded string
+// values anywhere in config, searching each decoded config in turn. Decoded
+// configs are fragments: rules looking for missing fields don't run on them.
+// location and path say where config itself was found ("" and "$" for the
+// file).
+func decodeBase64Configs(config *Config, location, path string, opts ParseOptions, depth int) []embeddedConfig {
+	if depth >= maxBase64Depth {
+		return nil
+	}
+	var leaves []stringLeaf
+	collectStrings(config.Data, "", "$", &leaves)
+
+	var decoded []embeddedConfig
+	for _, leaf := range leaves {
+		data := decodeBase64Config(leaf.value.(string))
+		if data == nil {
+			continue
+		}
+		inner := &Config{Data: data, FilePath: config.FilePath}
+		if opts.NormalizeValues {
+			NormalizeConfig(inner)
+		}
+
+		e := embeddedConfig{
+			location: leaf.location + " (base64)",
+			path:     path + strings.TrimPrefix(leaf.path, "$"),
+			config:   inner,
+			fragment: true,
+		}
+		if location != "" {
+			e.location = location + ": " + e.location
+		}
+		decoded = append(decoded, e)
+		decoded = append(decoded, decodeBase64Configs(inner, e.location, e.path, opts, depth+1)...)
+	}
+	return decoded
+}
+
+// decodeBase64Config decodes value and parses it as a config, returning nil
+// unless value is at least minBase64Length characters of base64 (line
+// breaks allowed) that decode to printable text holding a non-empty JSON,
+// YAML, or TOML object. Ordinary text that happens to be valid base64
+// decodes to binary noise and is skipped.
+func decodeBase64Config(value string) map[string]interface{} {
+	value = strings.Join(strings.Fields(value), "")
+	if len(value) < minBase64Length || !base64Value.MatchString(value) {
+		return nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		if decoded, err = base64.RawStdEncoding.DecodeString(value); err != nil {
+			return nil
+		}
+	}
+	if !isPrintableText(decoded) {
+		return nil
+	}
+	data, err := autoDetectFormat(decoded)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return data
+}
+
+// isPrintableText reports whether data is UTF-8 text without control
+// characters other than tabs and line breaks
+func isPrintableText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if r != '\n' && r != '\r' && r != '\t' && !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// relocate prefixes a finding from an embedded config with the location of
+// the value the config was read from
+func (e embeddedConfig) relocate(finding Finding) Finding {
+	finding.Location = e.location + ": " + finding.Location
+	if finding.Path != "" {
+		finding.Path = e.path + strings.TrimPrefix(finding.Path, "$")
+		finding.Pointer = JSONPointer(finding.Path)
+	}
+	return finding
+}
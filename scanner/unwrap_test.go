@@ -0,0 +1,347 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const unwrapRules = `
+version: "1.0.0"
+rules:
+  - id: SECRET_001
+    name: "API Key Found"
+    severity: CRITICAL
+    check:
+      type: pattern_match
+      patterns:
+        - "sk-[a-zA-Z0-9]{10,}"
+    fields:
+      - api_key
+  - id: TEMP_001
+    name: "High Temperature"
+    severity: HIGH
+    check:
+      type: numeric_range
+      parameter: temperature
+      max: 1.0
+`
+
+func TestScanner_UnwrapEmbeddedConfigs(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte(unwrapRules), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+
+	secretYAML := base64.StdEncoding.EncodeToString([]byte("api_key: sk-abcdefghijklmnop\n"))
+
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		unwrapKey string
+		expected  map[string]string // rule ID -> location
+		paths     map[string]string // rule ID -> path
+		wantErr   bool
+	}{
+		{
+			name: "configmap with embedded yaml",
+			file: "configmap.yaml",
+			content: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: llm-config
+data:
+  config.yaml: |
+    model: gpt-4
+    temperature: 0.5
+    api_key: sk-abcdefghijklmnop
+`,
+			expected: map[string]string{"SECRET_001": "data.config.yaml: api_key"},
+			paths:    map[string]string{"SECRET_001": "$.data['config.yaml'].api_key"},
+		},
+		{
+			name: "configmap with json blob and plain values",
+			file: "configmap.yaml",
+			content: `apiVersion: v1
+kind: ConfigMap
+data:
+  settings: '{"temperature": 1.8}'
+  api_key: sk-abcdefghijklmnop
+`,
+			expected: map[string]string{
+				"SECRET_001": "data: api_key",
+				"TEMP_001":   "data.settings: temperature",
+			},
+			paths: map[string]string{
+				"SECRET_001": "$.data.api_key",
+				"TEMP_001":   "$.data.settings.temperature",
+			},
+		},
+		{
+			name: "secret with base64 data",
+			file: "secret.yaml",
+			content: `apiVersion: v1
+kind: Secret
+data:
+  config.yaml: ` + secretYAML + `
+`,
+			expected: map[string]string{"SECRET_001": "data.config.yaml: api_key"},
+		},
+		{
+			name: "configmap embedded in a configmap",
+			file: "nested.yaml",
+			content: `apiVersion: v1
+kind: ConfigMap
+data:
+  inner.yaml: |
+    apiVersion: v1
+    kind: ConfigMap
+    data:
+      config.json: '{"temperature": 1.5}'
+`,
+			expected: map[string]string{"TEMP_001": "data.inner.yaml: data.config.json: temperature"},
+			paths:    map[string]string{"TEMP_001": "$.data['inner.yaml'].data['config.json'].temperature"},
+		},
+		{
+			name:      "explicit unwrap key",
+			file:      "wrapper.json",
+			content:   `{"payload": {"llm.yaml": "temperature: 1.9\n"}, "temperature": 0.1}`,
+			unwrapKey: "payload",
+			expected:  map[string]string{"TEMP_001": "payload.llm.yaml: temperature"},
+		},
+		{
+			name:     "without unwrapping the outer document is scanned",
+			file:     "plain.json",
+			content:  `{"payload": {"llm.yaml": "temperature: 1.9\n"}, "temperature": 1.2}`,
+			expected: map[string]string{"TEMP_001": "temperature"},
+		},
+		{
+			name: "invalid embedded config",
+			file: "broken.yaml",
+			content: `apiVersion: v1
+kind: ConfigMap
+data:
+  config.json: '{"temperature": '
+`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewScanner(rulesFile)
+			if err != nil {
+				t.Fatalf("failed to create scanner: %v", err)
+			}
+			s.ParseOptions.UnwrapKey = tt.unwrapKey
+
+			configFile := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			result, err := s.ScanFile(configFile)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "data.config.json") {
+					t.Errorf("expected an error naming the embedded config, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(result.Findings) != len(tt.expected) {
+				t.Fatalf("expected %d findings, got %+v", len(tt.expected), result.Findings)
+			}
+			for _, finding := range result.Findings {
+				location, ok := tt.expected[finding.RuleID]
+				if !ok {
+					t.Errorf("unexpected finding %s at %s", finding.RuleID, finding.Location)
+					continue
+				}
+				if finding.Location != location {
+					t.Errorf("expected %s at %q, got %q", finding.RuleID, location, finding.Location)
+				}
+				if path, ok := tt.paths[finding.RuleID]; ok {
+					if finding.Path != path {
+						t.Errorf("expected %s path %q, got %q", finding.RuleID, path, finding.Path)
+					}
+					if finding.Pointer != JSONPointer(path) {
+						t.Errorf("expected pointer %q, got %q", JSONPointer(path), finding.Pointer)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestScanner_UnwrapPlainValuesAreAFragment(t *testing.T) {
+	rules := RulesFile{Rules: []Rule{
+		{ID: "RATE_001", Severity: "CRITICAL", Check: Check{Type: "missing_field", Field: "rate_limit"}},
+		{ID: "DEBUG_001", Severity: "LOW", Check: Check{Type: "field_check", Field: "LOG_LEVEL", Values: []interface{}{"debug"}}},
+	}}
+	s, err := newScanner(rules)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string // rule ID -> location
+	}{
+		{
+			name: "plain values beside an embedded config",
+			content: `apiVersion: v1
+kind: ConfigMap
+data:
+  config.yaml: |
+    rate_limit: 60
+  LOG_LEVEL: debug
+`,
+			expected: map[string]string{"DEBUG_001": "data: LOG_LEVEL"},
+		},
+		{
+			name: "plain values only",
+			content: `apiVersion: v1
+kind: ConfigMap
+data:
+  LOG_LEVEL: info
+`,
+			expected: map[string]string{"RATE_001": "data: rate_limit"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "configmap.yaml")
+			if err := os.WriteFile(configFile, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			result, err := s.ScanFile(configFile)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := make(map[string]string)
+			for _, finding := range result.Findings {
+				got[finding.RuleID] = finding.Location
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("findings = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestScanner_DecodeBase64Values(t *testing.T) {
+	tmpDir := t.TempDir()
+	rulesFile := filepath.Join(tmpDir, "rules.yaml")
+	if err := os.WriteFile(rulesFile, []byte(unwrapRules), 0644); err != nil {
+		t.Fatalf("failed to write rules file: %v", err)
+	}
+	s, err := NewScanner(rulesFile)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("model: gpt-4\napi_key: sk-abcdefghijklmnop\n"))
+	nested := base64.StdEncoding.EncodeToString([]byte(`{"temperature": 1.7}`))
+	content := `{
+  "deploy": {"config": "` + encoded + `"},
+  "layers": ["` + base64.StdEncoding.EncodeToString([]byte("inner: "+nested)) + `"],
+  "release": "ThisIsAPlainWordValue",
+  "notes": "` + base64.StdEncoding.EncodeToString([]byte("just a sentence, not a config")) + `",
+  "temperature": 0.5
+}`
+	configFile := filepath.Join(tmpDir, "values.json")
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if len(result.Findings) != 0 {
+		t.Fatalf("expected encoded values to be opaque by default, got %v", findingIDs(result.Findings))
+	}
+
+	s.ParseOptions.DecodeBase64Values = true
+	s.RecordPassed = true
+	result, err = s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, finding := range result.Findings {
+		got[finding.RuleID] = finding.Location + " " + finding.Path
+	}
+	want := map[string]string{
+		"SECRET_001": "deploy.config (base64): api_key $.deploy.config.api_key",
+		"TEMP_001":   "layers.0 (base64): inner (base64): temperature $.layers[0].inner.temperature",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+	if len(result.Passed) != 0 {
+		t.Errorf("expected no rule to pass across the decoded configs, got %v", result.Passed)
+	}
+}
+
+func TestScanner_DecodeBase64ValuesSkipsMissingFields(t *testing.T) {
+	rules := RulesFile{Rules: []Rule{
+		{ID: "TOKENS_001", Severity: "HIGH", Check: Check{Type: "missing_field", Field: "max_tokens"}},
+		{ID: "MONITOR_001", Severity: "LOW", Check: Check{Type: "missing_fields", Fields: []string{"user_id", "session_id"}}},
+		{ID: "DEBUG_001", Severity: "LOW", Check: Check{Type: "field_check", Field: "log_level", Values: []interface{}{"debug"}}},
+		{
+			ID:          "TOKENS_002",
+			Severity:    "MEDIUM",
+			AppliesWhen: []Condition{{Parameter: "max_tokens", Operator: "exists"}},
+			Check:       Check{Type: "numeric_range", Parameter: "max_tokens", Max: 4096},
+		},
+	}}
+	s, err := newScanner(rules)
+	if err != nil {
+		t.Fatalf("failed to create scanner: %v", err)
+	}
+	s.RecordPassed = true
+
+	encoded := base64.StdEncoding.EncodeToString([]byte("log_level: debug\nregion: us-east-1\n"))
+	configFile := filepath.Join(t.TempDir(), "values.yaml")
+	content := "max_tokens: 512\nannotations:\n  meta: " + encoded + "\n"
+	if err := os.WriteFile(configFile, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	result, err := s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+	if !reflect.DeepEqual(findingIDs(result.Findings), []string{"MONITOR_001"}) {
+		t.Fatalf("expected only MONITOR_001 without decoding, got %v", findingIDs(result.Findings))
+	}
+
+	s.ParseOptions.DecodeBase64Values = true
+	result, err = s.ScanFile(configFile)
+	if err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	// The decoded value adds its own finding but no missing field findings
+	if want := []string{"DEBUG_001", "MONITOR_001"}; !reflect.DeepEqual(findingIDs(result.Findings), want) {
+		t.Errorf("findings = %v, want %v", findingIDs(result.Findings), want)
+	}
+	// TOKENS_002 passed on the file and its applies_when skips it on the
+	// decoded value, which must not undo the pass
+	if want := []string{"TOKENS_001", "TOKENS_002"}; !reflect.DeepEqual(result.Passed, want) {
+		t.Errorf("Passed = %v, want %v", result.Passed, want)
+	}
+}
@@ -0,0 +1,89 @@
+package scanner
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// defaultAllowedSchemes applies to url_scheme checks without
+// allowed_schemes
+var defaultAllowedSchemes = []string{"https"}
+
+// checkURLScheme flags URLs whose scheme is not in allowed_schemes (default
+// https) and, with block_private_hosts, URLs pointing at localhost or a
+// loopback, private, link-local, or unspecified address. It inspects
+// check.field, check.fields or the rule's fields, and otherwise every string
+// value containing "://". Values that do not parse as a URL with a host are
+// skipped. Every flagged value is reported.
+func checkURLScheme(rule Rule, config *Config) []checkResult {
+	check := rule.Check
+	fields := check.fieldNames()
+	if len(fields) == 0 {
+		fields = rule.Fields
+	}
+
+	var leaves []stringLeaf
+	if len(fields) == 0 {
+		collectStrings(config.Data, "", "$", &leaves)
+	}
+	for _, field := range fields {
+		for _, found := range config.findFieldValues(field) {
+			collectStrings(found.value, field, found.path, &leaves)
+		}
+	}
+
+	allowed := check.AllowedSchemes
+	if len(allowed) == 0 {
+		allowed = defaultAllowedSchemes
+	}
+
+	var results []checkResult
+	for _, leaf := range leaves {
+		str := strings.TrimSpace(leaf.value.(string))
+		if !strings.Contains(str, "://") {
+			continue
+		}
+		u, err := url.Parse(str)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		detail := ""
+		if !containsFold(allowed, u.Scheme) {
+			detail = fmt.Sprintf("scheme %s is not allowed (allowed: %s)", u.Scheme, strings.Join(allowed, ", "))
+		} else if check.BlockPrivateHosts {
+			if kind := privateHost(u.Hostname()); kind != "" {
+				detail = fmt.Sprintf("host %s is %s", u.Hostname(), kind)
+			}
+		}
+		if detail != "" {
+			results = append(results, checkResult{violated: true, location: leaf.location, path: leaf.path, evidence: u.Redacted(), detail: detail})
+		}
+	}
+	return results
+}
+
+// privateHost describes host when it is localhost or a loopback, private,
+// link-local, or unspecified IP address, and returns "" otherwise
+func privateHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return "localhost"
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.IsLoopback():
+		return "a loopback address"
+	case ip.IsPrivate():
+		return "a private address"
+	case ip.IsLinkLocalUnicast():
+		return "a link-local address"
+	case ip.IsUnspecified():
+		return "an unspecified address"
+	}
+	return ""
+}
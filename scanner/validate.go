@@ -0,0 +1,132 @@
+package scanner
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// commonCheckKeys are the check keys every check type honors
+var commonCheckKeys = []string{"type", "negate", "case_insensitive"}
+
+// checkKeys lists the keys each built-in check type reads besides
+// commonCheckKeys. Every type that takes field names accepts both field
+// and fields.
+var checkKeys = map[string][]string{
+	"numeric_range":            {"parameter", "parameters", "field", "fields", "path", "min", "max", "condition"},
+	"missing_field":            {"field", "fields"},
+	"missing_fields":           {"field", "fields"},
+	"mutually_exclusive":       {"field", "fields"},
+	"field_exists":             {"field", "fields", "path"},
+	"deprecated_field":         {"field", "fields", "path", "replaced_by"},
+	"field_type":               {"field", "fields", "expected_type"},
+	"count":                    {"field", "fields", "min_count", "max_count"},
+	"field_check":              {"field", "fields", "values", "missing_all"},
+	"stop_sequence_complexity": {"field", "fields", "max_sequences", "max_length"},
+	"pattern_match":            {"field", "fields", "path", "patterns", "flags", "anchored", "allowlist", "redact"},
+	"entropy":                  {"field", "fields", "path", "min_entropy", "min_length", "redact"},
+	"requires_interpolation":   {"field", "fields", "path", "redact"},
+	"secret_scan":              {"min_entropy", "min_length", "allowlist", "flags", "redact"},
+	"url_scheme":               {"field", "fields", "allowed_schemes", "block_private_hosts"},
+	"key_pattern":              {"patterns", "flags", "anchored", "allow"},
+	"context_window_exceeded":  {"context_windows", "default_context_window"},
+	"combined_conditions":      {"conditions", "require", "require_count"},
+	"conditional_missing":      {"has_any", "missing_all"},
+}
+
+// fieldNames returns the check's field and fields as one list, field
+// first, without duplicates. Rules loaded by a Scanner already hold the
+// merged list in Fields.
+func (c Check) fieldNames() []string {
+	if c.Field == "" {
+		return c.Fields
+	}
+	names := []string{c.Field}
+	for _, field := range c.Fields {
+		if field != c.Field {
+			names = append(names, field)
+		}
+	}
+	return names
+}
+
+// takesFields reports whether a built-in check type reads field names
+func takesFields(checkType string) bool {
+	for _, key := range checkKeys[checkType] {
+		if key == "fields" {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeFields merges field into fields for every check whose type
+// takes field names, so checks read a single list. Other checks, including
+// custom ones, are left as written.
+func normalizeFields(rules *RulesFile) {
+	normalize := func(check *Check) {
+		if takesFields(check.Type) {
+			check.Fields = check.fieldNames()
+			check.Field = ""
+		}
+	}
+	for i := range rules.Rules {
+		normalize(&rules.Rules[i].Check)
+		for j := range rules.Rules[i].Checks {
+			normalize(&rules.Rules[i].Checks[j])
+		}
+	}
+}
+
+// ValidateRules returns a warning for every key a rule's check sets that its
+// check type ignores, such as max_length on numeric_range, which would
+// otherwise be a silent no-op. Custom check types registered with
+// RegisterCheck are not checked.
+func ValidateRules(rules []Rule) []string {
+	var warnings []string
+	for _, rule := range rules {
+		checks := rule.Checks
+		if len(checks) == 0 {
+			checks = []Check{rule.Check}
+		}
+		for _, check := range checks {
+			for _, key := range ignoredCheckKeys(check) {
+				warnings = append(warnings, fmt.Sprintf("rule %s: %s check ignores %s", rule.ID, check.Type, key))
+			}
+		}
+	}
+	return warnings
+}
+
+// ignoredCheckKeys lists, in declaration order, the keys check sets that its
+// type does not read
+func ignoredCheckKeys(check Check) []string {
+	used, ok := checkKeys[check.Type]
+	if !ok {
+		return nil
+	}
+	used = append(used, commonCheckKeys...)
+
+	var ignored []string
+	v := reflect.ValueOf(check)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" || v.Field(i).IsZero() {
+			continue
+		}
+		if !containsString(used, key) {
+			ignored = append(ignored, key)
+		}
+	}
+	return ignored
+}
+
+func containsString(values []string, want string) bool {
+	for _, value := range values {
+		if value == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want []string
+	}{
+		{
+			name: "ignored key",
+			rule: Rule{ID: "BAD_001", Check: Check{Type: "numeric_range", Parameter: "temperature", Max: 1, MaxLength: 10}},
+			want: []string{"rule BAD_001: numeric_range check ignores max_length"},
+		},
+		{
+			name: "ignored keys in checks",
+			rule: Rule{ID: "BAD_002", Checks: []Check{
+				{Type: "field_exists", Field: "seed", Patterns: []string{"x"}},
+				{Type: "missing_field", Field: "model", Operator: "equals", Value: "gpt-4"},
+			}},
+			want: []string{
+				"rule BAD_002: field_exists check ignores patterns",
+				"rule BAD_002: missing_field check ignores operator",
+				"rule BAD_002: missing_field check ignores value",
+			},
+		},
+		{
+			name: "fields on a single-field check",
+			rule: Rule{ID: "OK_001", Check: Check{Type: "field_type", Fields: []string{"stop"}, ExpectedType: "array", Negate: true}},
+		},
+		{
+			name: "custom check type",
+			rule: Rule{ID: "OK_002", Check: Check{Type: "my_check", MaxLength: 10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ValidateRules([]Rule{tt.rule}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ValidateRules() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRules_DefaultRules(t *testing.T) {
+	s, err := NewScannerWithDefaults()
+	if err != nil {
+		t.Fatalf("NewScannerWithDefaults() error = %v", err)
+	}
+	if warnings := ValidateRules(s.Rules()); len(warnings) > 0 {
+		t.Errorf("expected no warnings for the default rules, got %q", warnings)
+	}
+}
+
+func TestNewScanner_NormalizesFields(t *testing.T) {
+	s, err := newScanner(RulesFile{Rules: []Rule{
+		{ID: "FIELD_001", Check: Check{Type: "field_exists", Field: "seed", Fields: []string{"logit_bias", "seed"}}},
+		{ID: "FIELD_002", Checks: []Check{{Type: "count", Field: "stop", MaxCount: 4}}},
+		{ID: "FIELD_003", Check: Check{Type: "combined_conditions", Field: "seed"}},
+	}})
+	if err != nil {
+		t.Fatalf("newScanner() error = %v", err)
+	}
+
+	rules := s.Rules()
+	if check := rules[0].Check; check.Field != "" || !reflect.DeepEqual(check.Fields, []string{"seed", "logit_bias"}) {
+		t.Errorf("FIELD_001: expected fields [seed logit_bias], got field %q fields %v", check.Field, check.Fields)
+	}
+	if check := rules[1].Checks[0]; check.Field != "" || !reflect.DeepEqual(check.Fields, []string{"stop"}) {
+		t.Errorf("FIELD_002: expected fields [stop], got field %q fields %v", check.Field, check.Fields)
+	}
+	if check := rules[2].Check; check.Field != "seed" || check.Fields != nil {
+		t.Errorf("FIELD_003: expected the check left as written, got field %q fields %v", check.Field, check.Fields)
+	}
+}